@@ -1,75 +1,771 @@
 package mcache
 
 import (
+	"container/heap"
+	"container/list"
+	"context"
+	"hash/maphash"
+	"math/rand/v2"
+	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
+	"weak"
 )
 
-type item[V any] struct {
+const NoExpiration time.Duration = -1
+
+type item[K comparable, V any] struct {
+	key        K
 	value      V
 	expiryTime time.Time
+	elem       *list.Element
+	slot       int
+	index      int
+	cost       int64
+	version    uint64
+	created    time.Time
+	accessed   atomic.Int64
+	hits       atomic.Uint64
+}
+
+func (it *item[K, V]) expired(now time.Time) bool {
+	return !it.expiryTime.IsZero() && !now.Before(it.expiryTime)
+}
+
+type Entry[V any] struct {
+	Value      V
+	ExpiryTime time.Time
 }
 
 type Cache[K comparable, V any] struct {
-	mu              sync.Mutex
-	items           map[K]item[V]
+	mu              sync.RWMutex
+	items           map[K]*item[K, V]
+	order           *list.List
+	slots           []*item[K, V]
+	expiries        expiryHeap[K, V]
 	ttl             time.Duration
 	cleanupInterval time.Duration
+	cleanupBatch    int
+	grace           time.Duration
+	slidingTTL      bool
+	maxEntries      int
+	maxCost         int64
+	coster          func(key K, value V) int64
+	cost            int64
+	versions        uint64
+	policy          EvictionPolicy
+	jitter          float64
+	rnd             *rand.Rand
+	clock           Clock
 	done            chan struct{}
+	stopped         chan struct{}
+	collect         runtime.Cleanup
+	closed          bool
+	stats           stats
+
+	onEvict       func(key K, value V, reason EvictReason)
+	pending       []Event[K, V]
+	evictedKeys   *[]K
+	events        chan Event[K, V]
+	subs          map[chan Event[K, V]]struct{}
+	eventBuffer   int
+	droppedEvents atomic.Uint64
+
+	stripes     []loadStripe[K, V]
+	stripeSeed  maphash.Seed
+	loadSlots   chan struct{}
+	computeHook ComputeHook[K]
+	onLoadError func(key K, err error)
+}
+
+func NewCache[K comparable, V any](opts ...Option[K, V]) *Cache[K, V] {
+	return NewCacheContext(context.Background(), opts...)
 }
 
-func NewCache[K comparable, V any](ttl, cleanupInterval time.Duration) *Cache[K, V] {
+func NewCacheContext[K comparable, V any](ctx context.Context, opts ...Option[K, V]) *Cache[K, V] {
+	o := defaultOptions[K, V]()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.ttl <= 0 {
+		o.ttl = NoExpiration
+	}
+
+	if o.eventBuffer <= 0 {
+		o.eventBuffer = eventBufferSize
+	}
+
+	if o.rnd == nil {
+		o.rnd = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+
+	if !o.cleanupIntervalSet && o.ttl > 0 {
+		o.cleanupInterval = o.ttl
+	}
+
 	c := &Cache[K, V]{
-		items:           make(map[K]item[V]),
-		ttl:             ttl,
-		cleanupInterval: cleanupInterval,
+		items:           make(map[K]*item[K, V]),
+		order:           list.New(),
+		ttl:             o.ttl,
+		cleanupInterval: o.cleanupInterval,
+		cleanupBatch:    o.cleanupBatch,
+		grace:           max(o.grace, 0),
+		slidingTTL:      o.slidingTTL,
+		maxEntries:      o.maxEntries,
+		maxCost:         o.maxCost,
+		coster:          o.coster,
+		policy:          o.policy,
+		jitter:          min(o.jitter, 1),
+		rnd:             o.rnd,
+		onEvict:         o.onEvict,
+		eventBuffer:     o.eventBuffer,
+		clock:           o.clock,
 		done:            make(chan struct{}),
+		stripes:         newLoadStripes[K, V](o.loadStripes),
+		stripeSeed:      maphash.MakeSeed(),
+		computeHook:     o.computeHook,
+		onLoadError:     o.onLoadError,
+	}
+
+	if o.maxLoads > 0 {
+		c.loadSlots = make(chan struct{}, o.maxLoads)
 	}
 
-	go c.cleanup()
+	if c.cleanupInterval > 0 {
+		c.stopped = make(chan struct{})
+		go cleanup(ctx, weak.Make(c), c.cleanupInterval, c.done, c.stopped)
+
+		// The cleanup goroutine only holds a weak pointer, so a cache that is
+		// dropped without Close can still be collected and stop it.
+		c.collect = runtime.AddCleanup(c, func(done chan struct{}) { close(done) }, c.done)
+	}
 
 	return c
 }
 
 func (c *Cache[K, V]) Close() {
-	close(c.done)
+	c.mu.Lock()
+	if !c.closed {
+		c.closed = true
+		c.collect.Stop()
+		close(c.done)
+
+		for ch := range c.subs {
+			close(ch)
+		}
+		c.subs = nil
+	}
+	c.mu.Unlock()
+
+	// The cleanup goroutine may be mid-sweep and needs the lock to finish.
+	if c.stopped != nil {
+		<-c.stopped
+	}
+}
+
+func (c *Cache[K, V]) IsClosed() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.closed
 }
 
 func (c *Cache[K, V]) GetAll() map[K]V {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 
-	now := time.Now()
+	now := c.clock.Now()
 	result := make(map[K]V, len(c.items))
 
 	for k, it := range c.items {
-		if now.Before(it.expiryTime) {
+		if !it.expired(now) {
 			result[k] = it.value
-		} else {
-			delete(c.items, k)
 		}
 	}
 
 	return result
 }
 
+func (c *Cache[K, V]) GetAllFunc(pred func(key K, value V) bool) map[K]V {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := c.clock.Now()
+	result := make(map[K]V)
+
+	for k, it := range c.items {
+		if !it.expired(now) && pred(k, it.value) {
+			result[k] = it.value
+		}
+	}
+
+	return result
+}
+
+func (c *Cache[K, V]) Snapshot() map[K]Entry[V] {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := c.clock.Now()
+	result := make(map[K]Entry[V], len(c.items))
+
+	for k, it := range c.items {
+		if !it.expired(now) {
+			result[k] = Entry[V]{Value: it.value, ExpiryTime: it.expiryTime}
+		}
+	}
+
+	return result
+}
+
+func (c *Cache[K, V]) Keys() []K {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := c.clock.Now()
+	keys := make([]K, 0, len(c.items))
+
+	for k, it := range c.items {
+		if !it.expired(now) {
+			keys = append(keys, k)
+		}
+	}
+
+	return keys
+}
+
+func (c *Cache[K, V]) Values() []V {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := c.clock.Now()
+	values := make([]V, 0, len(c.items))
+
+	for _, it := range c.items {
+		if !it.expired(now) {
+			values = append(values, it.value)
+		}
+	}
+
+	return values
+}
+
+func (c *Cache[K, V]) Range(fn func(key K, value V) bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := c.clock.Now()
+
+	for k, it := range c.items {
+		if it.expired(now) {
+			continue
+		}
+		if !fn(k, it.value) {
+			return
+		}
+	}
+}
+
+func (c *Cache[K, V]) TTL() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.ttl
+}
+
+func (c *Cache[K, V]) SetDefaultTTL(ttl time.Duration) {
+	c.mu.Lock()
+	defer c.unlock()
+
+	if ttl <= 0 {
+		ttl = NoExpiration
+	}
+	c.ttl = ttl
+}
+
 func (c *Cache[K, V]) Set(key K, value V) {
+	c.SetWithTTL(key, value, 0)
+}
+
+func (c *Cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.unlock()
+
+	c.set(key, value, ttl)
+}
+
+func (c *Cache[K, V]) SetMany(items map[K]V) {
+	c.SetManyWithTTL(items, 0)
+}
+
+func (c *Cache[K, V]) SetManyWithTTL(items map[K]V, ttl time.Duration) {
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	defer c.unlock()
+
+	for k, v := range items {
+		c.set(k, v, ttl)
+	}
+}
+
+func (c *Cache[K, V]) SetManyEvicting(items map[K]V) []K {
+	c.mu.Lock()
+	defer c.unlock()
+
+	var evicted []K
+	c.evictedKeys = &evicted
+	for k, v := range items {
+		c.set(k, v, 0)
+	}
+	c.evictedKeys = nil
+
+	return evicted
+}
+
+func (c *Cache[K, V]) SetManyStaggered(items map[K]V, spread time.Duration) {
+	c.mu.Lock()
+	defer c.unlock()
+
+	if len(items) == 0 {
+		return
+	}
+
+	if c.ttl <= 0 || spread <= 0 {
+		for k, v := range items {
+			c.set(k, v, c.ttl)
+		}
+		return
+	}
+
+	now := c.clock.Now()
+	step := spread / time.Duration(len(items))
+	i := 0
+
+	for k, v := range items {
+		c.setAt(k, v, now.Add(c.ttl+time.Duration(i)*step))
+		i++
+	}
+}
+
+func (c *Cache[K, V]) GetOrSet(key K, value V) (V, bool) {
+	c.mu.Lock()
+	defer c.unlock()
+
+	now := c.clock.Now()
+
+	if it, ok := c.lookup(key, now); ok {
+		c.access(it, now)
+		return it.value, true
+	}
+
+	c.set(key, value, c.ttl)
+	return value, false
+}
+
+func (c *Cache[K, V]) SetNX(key K, value V) bool {
+	c.mu.Lock()
+	defer c.unlock()
+
+	if _, ok := c.lookup(key, c.clock.Now()); ok {
+		return false
+	}
+
+	if c.closed {
+		return false
+	}
+
+	c.set(key, value, c.ttl)
+	return true
+}
+
+// setIfAbsent stores value unless key already holds a live entry or valid
+// reports that the value has been superseded. It lets a read-through fill
+// the cache without overwriting a write that ran while the value was read.
+func (c *Cache[K, V]) setIfAbsent(key K, value V, ttl time.Duration, valid func() bool) bool {
+	c.mu.Lock()
+	defer c.unlock()
+
+	if _, ok := c.lookup(key, c.clock.Now()); ok || c.closed {
+		return false
+	}
+	if valid != nil && !valid() {
+		return false
+	}
+
+	c.set(key, value, ttl)
+	return true
+}
+
+func (c *Cache[K, V]) Replace(key K, value V) bool {
+	c.mu.Lock()
+	defer c.unlock()
+
+	if _, ok := c.lookup(key, c.clock.Now()); !ok || c.closed {
+		return false
+	}
+
+	c.set(key, value, c.ttl)
+	return true
+}
+
+func (c *Cache[K, V]) SetValue(key K, value V) bool {
+	c.mu.Lock()
+	defer c.unlock()
+
+	it, ok := c.lookup(key, c.clock.Now())
+	if !ok || c.closed {
+		return false
+	}
+
+	c.setAt(key, value, it.expiryTime)
+	return true
+}
+
+func (c *Cache[K, V]) SetWithExpiry(key K, value V, expiry time.Time) {
+	c.mu.Lock()
+	defer c.unlock()
+
+	if !c.clock.Now().Before(expiry) {
+		if !c.closed {
+			c.remove(key, Deleted)
+		}
+		return
+	}
+
+	c.setAt(key, value, expiry)
+}
+
+func (c *Cache[K, V]) set(key K, value V, ttl time.Duration) {
+	c.setCost(key, value, ttl, c.costOf(key, value))
+}
+
+func (c *Cache[K, V]) setCost(key K, value V, ttl time.Duration, cost int64) {
+	if ttl == 0 {
+		ttl = c.ttl
+	}
+
+	switch {
+	case ttl == NoExpiration:
+		c.setAtCost(key, value, time.Time{}, cost)
+	case ttl < 0:
+		if !c.closed {
+			c.remove(key, Deleted)
+		}
+	default:
+		c.setAtCost(key, value, c.clock.Now().Add(c.jittered(ttl)), cost)
+	}
+}
+
+func (c *Cache[K, V]) setAt(key K, value V, expiryTime time.Time) {
+	c.setAtCost(key, value, expiryTime, c.costOf(key, value))
+}
+
+func (c *Cache[K, V]) setAtCost(key K, value V, expiryTime time.Time, cost int64) {
+	if c.closed {
+		return
+	}
+
+	if c.maxCost > 0 && cost > c.maxCost {
+		// The value can never fit, so it is dropped together with the entry
+		// it would have replaced.
+		if _, ok := c.remove(key, Evicted); ok {
+			c.evicted(key)
+		}
+		return
+	}
+
+	if it, ok := c.lookup(key, c.clock.Now()); ok {
+		c.notify(key, it.value, Replaced)
+		c.makeRoom(it, cost-it.cost)
+		c.cost += cost - it.cost
+		it.value = value
+		it.cost = cost
+		it.version = c.nextVersion()
+		c.setExpiryTime(it, expiryTime)
+		c.touchOrder(it)
+		return
+	}
+
+	// A stale entry kept for its grace period is replaced by a new one.
+	if _, ok := c.items[key]; ok {
+		c.expire(key)
+	}
+
+	c.makeRoom(nil, cost)
+
+	it := &item[K, V]{
+		key:     key,
+		value:   value,
+		cost:    cost,
+		version: c.nextVersion(),
+		index:   -1,
+		created: c.clock.Now(),
+	}
+	c.setExpiryTime(it, expiryTime)
+	c.track(it)
+
+	c.items[key] = it
+	c.cost += cost
+}
+
+// lookup treats an expired entry as absent, but leaves it in place while it
+// is within the grace period so it can still be served as stale.
+func (c *Cache[K, V]) lookup(key K, now time.Time) (*item[K, V], bool) {
+	it, ok := c.items[key]
+	if ok && it.expired(now) {
+		if it.expired(now.Add(-c.grace)) {
+			c.expire(key)
+		}
+		return nil, false
+	}
+
+	return it, ok
+}
+
+func (c *Cache[K, V]) stale(key K) (V, bool, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := c.clock.Now()
 
-	c.items[key] = item[V]{
-		value:      value,
-		expiryTime: time.Now().Add(c.ttl),
+	it, ok := c.items[key]
+	if !ok || it.expired(now.Add(-c.grace)) {
+		var zero V
+		return zero, false, false
+	}
+
+	return it.value, it.expired(now), true
+}
+
+func (c *Cache[K, V]) remove(key K, reason EvictReason) (*item[K, V], bool) {
+	it, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	delete(c.items, key)
+	c.cost -= it.cost
+	c.untrack(it)
+	if it.index >= 0 {
+		heap.Remove(&c.expiries, it.index)
+	}
+	c.notify(key, it.value, reason)
+
+	return it, true
+}
+
+func (c *Cache[K, V]) expire(key K) {
+	if _, ok := c.remove(key, Expired); ok {
+		c.stats.expirations.Add(1)
 	}
 }
 
 func (c *Cache[K, V]) Get(key K) (V, bool) {
+	val, ok := c.get(key)
+	c.stats.hit(ok)
+	return val, ok
+}
+
+func (c *Cache[K, V]) GetStale(key K) (V, bool, bool) {
+	if val, ok := c.get(key); ok {
+		c.stats.hit(true)
+		return val, false, true
+	}
+
+	val, stale, ok := c.stale(key)
+	c.stats.hit(ok && !stale)
+	return val, stale, ok
+}
+
+func (c *Cache[K, V]) get(key K) (V, bool) {
+	c.mu.RLock()
+	if c.slidingTTL || (c.bounded() && c.policy == LRU) {
+		c.mu.RUnlock()
+		return c.getLocked(key)
+	}
+
+	it, ok := c.items[key]
+	if !ok {
+		c.mu.RUnlock()
+		var zero V
+		return zero, false
+	}
+
+	// Expired entries are left for the sweep or the next write to the key,
+	// so reads never need the write lock.
+	now := c.clock.Now()
+	value, expired := it.value, it.expired(now)
+	if !expired {
+		it.touch(now)
+	}
+	c.mu.RUnlock()
+
+	if expired {
+		var zero V
+		return zero, false
+	}
+
+	return value, true
+}
+
+func (c *Cache[K, V]) getLocked(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.unlock()
+
+	now := c.clock.Now()
+
+	it, ok := c.lookup(key, now)
+	if !ok {
+		var zero V
+		return zero, false
+	}
+
+	c.access(it, now)
+	return it.value, true
+}
+
+func (c *Cache[K, V]) access(it *item[K, V], now time.Time) {
+	if c.slidingTTL && c.ttl > 0 && !it.expiryTime.IsZero() {
+		c.setExpiryTime(it, now.Add(c.ttl))
+	}
+	it.touch(now)
+	c.touchOrder(it)
+}
+
+func (c *Cache[K, V]) GetMany(keys []K) map[K]V {
+	c.mu.Lock()
+	defer c.unlock()
+
+	now := c.clock.Now()
+	result := make(map[K]V, len(keys))
+
+	for _, k := range keys {
+		it, ok := c.lookup(k, now)
+		c.stats.hit(ok)
+		if ok {
+			c.access(it, now)
+			result[k] = it.value
+		}
+	}
+
+	return result
+}
+
+func (c *Cache[K, V]) GetManyOrDefault(keys []K, def V) map[K]V {
+	c.mu.Lock()
+	defer c.unlock()
+
+	now := c.clock.Now()
+	result := make(map[K]V, len(keys))
+
+	for _, k := range keys {
+		it, ok := c.lookup(k, now)
+		c.stats.hit(ok)
+		if ok {
+			c.access(it, now)
+			result[k] = it.value
+		} else {
+			result[k] = def
+		}
+	}
+
+	return result
+}
+
+func (c *Cache[K, V]) GetTTL(key K) (time.Duration, bool) {
+	c.mu.RLock()
+	it, ok := c.items[key]
+	if !ok {
+		c.mu.RUnlock()
+		return 0, false
+	}
+
+	expiryTime := it.expiryTime
+	c.mu.RUnlock()
+
+	now := c.clock.Now()
+
+	if expiryTime.IsZero() {
+		return NoExpiration, true
+	}
+
+	if !now.Before(expiryTime) {
+		return 0, false
+	}
+
+	return expiryTime.Sub(now), true
+}
+
+func (c *Cache[K, V]) Touch(key K) bool {
+	c.mu.Lock()
+	defer c.unlock()
+
+	now := c.clock.Now()
+
+	it, ok := c.lookup(key, now)
+	if !ok {
+		return false
+	}
+
+	if c.ttl > 0 && !it.expiryTime.IsZero() {
+		c.setExpiryTime(it, now.Add(c.ttl))
+	}
+
+	return true
+}
+
+func (c *Cache[K, V]) Expire(key K, ttl time.Duration) bool {
+	c.mu.Lock()
+	defer c.unlock()
+
+	now := c.clock.Now()
+
+	if ttl <= 0 {
+		c.expire(key)
+		return false
+	}
+
+	return c.setExpiry(key, now.Add(ttl), now)
+}
+
+func (c *Cache[K, V]) Persist(key K) bool {
+	c.mu.Lock()
+	defer c.unlock()
+
+	return c.setExpiry(key, time.Time{}, c.clock.Now())
+}
+
+func (c *Cache[K, V]) ExpireAt(key K, t time.Time) bool {
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	defer c.unlock()
+
+	now := c.clock.Now()
+
+	if !now.Before(t) {
+		c.expire(key)
+		return false
+	}
+
+	return c.setExpiry(key, t, now)
+}
+
+func (c *Cache[K, V]) setExpiry(key K, expiryTime, now time.Time) bool {
+	it, ok := c.lookup(key, now)
+	if !ok {
+		return false
+	}
+
+	c.setExpiryTime(it, expiryTime)
+	return true
+}
+
+func (c *Cache[K, V]) Peek(key K) (V, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 
 	it, ok := c.items[key]
-	if !ok || time.Now().After(it.expiryTime) {
-		delete(c.items, key)
+	if !ok || it.expired(c.clock.Now()) {
 		var zero V
 		return zero, false
 	}
@@ -77,64 +773,199 @@ func (c *Cache[K, V]) Get(key K) (V, bool) {
 	return it.value, true
 }
 
+func (c *Cache[K, V]) Has(key K) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	it, ok := c.items[key]
+	return ok && !it.expired(c.clock.Now())
+}
+
 func (c *Cache[K, V]) Count() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := c.clock.Now()
+	count := 0
+
+	for _, it := range c.items {
+		if !it.expired(now) {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (c *Cache[K, V]) CountLive() int {
+	return c.Count()
+}
+
+func (c *Cache[K, V]) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return len(c.items)
+}
+
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.unlock()
+
+	c.remove(key, Deleted)
+}
+
+func (c *Cache[K, V]) DeleteMany(keys []K) int {
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	defer c.unlock()
 
-	now := time.Now()
+	now := c.clock.Now()
+	count := 0
+
+	for _, k := range keys {
+		if _, ok := c.lookup(k, now); ok {
+			c.remove(k, Deleted)
+			count++
+		}
+	}
+
+	return count
+}
+
+func (c *Cache[K, V]) DeleteFunc(pred func(key K, value V) bool) int {
+	c.mu.Lock()
+	defer c.unlock()
+
+	now := c.clock.Now()
 	count := 0
 
 	for k, it := range c.items {
-		if now.Before(it.expiryTime) {
+		if !it.expired(now) && pred(k, it.value) {
+			c.remove(k, Deleted)
 			count++
-		} else {
-			delete(c.items, k)
 		}
 	}
 
 	return count
 }
 
-func (c *Cache[K, V]) Delete(key K) {
+func (c *Cache[K, V]) Clear() {
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	defer c.unlock()
 
-	delete(c.items, key)
+	for k, it := range c.items {
+		c.notify(k, it.value, Cleared)
+	}
+
+	c.items = make(map[K]*item[K, V])
+	c.order.Init()
+	c.slots = nil
+	c.expiries = nil
+	c.cost = 0
 }
 
-func (c *Cache[K, V]) Release(key K) (V, bool) {
+func (c *Cache[K, V]) GetAndDelete(key K) (V, bool) {
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	defer c.unlock()
 
-	it, ok := c.items[key]
-	if !ok || time.Now().After(it.expiryTime) {
-		delete(c.items, key)
+	it, ok := c.lookup(key, c.clock.Now())
+	if !ok {
 		var zero V
 		return zero, false
 	}
 
-	delete(c.items, key)
+	c.remove(key, Deleted)
 	return it.value, true
 }
 
-func (c *Cache[K, V]) cleanup() {
-	ticker := time.NewTicker(c.cleanupInterval)
+func (c *Cache[K, V]) Release(key K) (V, bool) {
+	return c.GetAndDelete(key)
+}
+
+func (c *Cache[K, V]) Pop() (K, V, bool) {
+	c.mu.Lock()
+	defer c.unlock()
+
+	now := c.clock.Now()
+
+	// The Random policy indexes its entries, so the choice can come from
+	// c.rnd and is reproducible with WithRand. Scanning on from the drawn slot
+	// skips expired entries without drawing again.
+	if n := len(c.slots); n > 0 {
+		start := c.rnd.IntN(n)
+		for j := range n {
+			it := c.slots[(start+j)%n]
+			if !it.expired(now) {
+				c.remove(it.key, Deleted)
+				return it.key, it.value, true
+			}
+		}
+	} else {
+		for k, it := range c.items {
+			if !it.expired(now) {
+				c.remove(k, Deleted)
+				return k, it.value, true
+			}
+		}
+	}
+
+	var (
+		zeroK K
+		zeroV V
+	)
+	return zeroK, zeroV, false
+}
+
+func (c *Cache[K, V]) DeleteExpired() int {
+	total := 0
+	for {
+		n, more := c.deleteExpiredBatch()
+		total += n
+		if !more {
+			return total
+		}
+	}
+}
+
+// deleteExpiredBatch removes up to cleanupBatch due entries under a
+// single lock acquisition and reports whether more may remain.
+func (c *Cache[K, V]) deleteExpiredBatch() (int, bool) {
+	c.mu.Lock()
+	defer c.unlock()
+
+	now := c.clock.Now()
+	count := 0
+
+	// The heap is ordered by expiry, so it is also ordered by the end of
+	// each entry's grace period.
+	for len(c.expiries) > 0 && c.expiries[0].expired(now.Add(-c.grace)) {
+		if c.cleanupBatch > 0 && count == c.cleanupBatch {
+			return count, true
+		}
+		c.expire(c.expiries[0].key)
+		count++
+	}
+
+	return count, false
+}
+
+func cleanup[K comparable, V any](ctx context.Context, ref weak.Pointer[Cache[K, V]], interval time.Duration, done, stopped chan struct{}) {
+	defer close(stopped)
+
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ticker.C:
-			now := time.Now()
-			c.mu.Lock()
-
-			for k, it := range c.items {
-				if now.After(it.expiryTime) {
-					delete(c.items, k)
-				}
+			c := ref.Value()
+			if c == nil {
+				return
 			}
-
-			c.mu.Unlock()
-		case <-c.done:
+			c.DeleteExpired()
+		case <-done:
+			return
+		case <-ctx.Done():
 			return
 		}
 	}
@@ -1,6 +1,11 @@
 package mcache
 
 import (
+	"context"
+	"maps"
+	"runtime"
+	"slices"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -11,8 +16,20 @@ const (
 	cleanupInterval = 50 * time.Millisecond
 )
 
-func newTestCache() *Cache[string, int] {
-	return NewCache[string, int](ttl, cleanupInterval)
+func newTestCache(opts ...Option[string, int]) *Cache[string, int] {
+	opts = append([]Option[string, int]{
+		WithTTL[string, int](ttl),
+		WithCleanupInterval[string, int](cleanupInterval),
+		WithClock[string, int](newFakeClock()),
+	}, opts...)
+	return NewCache(opts...)
+}
+
+func newTestCacheRealClock() *Cache[string, int] {
+	return NewCache(
+		WithTTL[string, int](ttl),
+		WithCleanupInterval[string, int](cleanupInterval),
+	)
 }
 
 func TestSet_Get(t *testing.T) {
@@ -43,12 +60,38 @@ func TestGet_MissingKey(t *testing.T) {
 	}
 }
 
+func TestGet_ExpiredNotDeleted(t *testing.T) {
+	c := newTestCache(WithCleanupInterval[string, int](0))
+	defer c.Close()
+
+	c.Set("a", 1)
+	advance(c, ttl)
+
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected key to be expired")
+	}
+	if _, ok := c.GetTTL("a"); ok {
+		t.Fatal("expected no ttl for expired key")
+	}
+
+	c.mu.Lock()
+	_, ok := c.items["a"]
+	c.mu.Unlock()
+
+	if !ok {
+		t.Fatal("expected reads to leave the expired entry for the sweep")
+	}
+	if n := c.DeleteExpired(); n != 1 {
+		t.Fatalf("expected sweep to reap 1 entry, got %d", n)
+	}
+}
+
 func TestGet_Expired(t *testing.T) {
 	c := newTestCache()
 	defer c.Close()
 
 	c.Set("a", 42)
-	time.Sleep(ttl + 10*time.Millisecond)
+	advance(c, ttl)
 
 	_, ok := c.Get("a")
 	if ok {
@@ -93,6 +136,86 @@ func TestDelete_MissingKey(t *testing.T) {
 	c.Delete("missing")
 }
 
+func TestGetAndDelete(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 10)
+
+	val, ok := c.GetAndDelete("a")
+	if !ok || val != 10 {
+		t.Fatalf("expected 10, got %d, %v", val, ok)
+	}
+	if c.Has("a") {
+		t.Fatal("expected key to be removed")
+	}
+
+	if _, ok := c.GetAndDelete("a"); ok {
+		t.Fatal("expected a second call to miss")
+	}
+}
+
+func TestGetAndDelete_Expired(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	advance(c, ttl)
+
+	if _, ok := c.GetAndDelete("a"); ok {
+		t.Fatal("expected expired key to miss")
+	}
+}
+
+func TestPop(t *testing.T) {
+	var deleted []string
+	c := newTestCache(WithOnEvict(func(key string, value int, reason EvictReason) {
+		if reason == Deleted {
+			deleted = append(deleted, key)
+		}
+	}))
+	defer c.Close()
+
+	want := map[string]int{"a": 1, "b": 2, "c": 3}
+	c.SetMany(want)
+
+	got := map[string]int{}
+	for range len(want) {
+		key, val, ok := c.Pop()
+		if !ok {
+			t.Fatal("expected an entry")
+		}
+		got[key] = val
+	}
+
+	if !maps.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if len(deleted) != 3 {
+		t.Fatalf("expected popped entries to be reported as deleted, got %v", deleted)
+	}
+	if _, _, ok := c.Pop(); ok {
+		t.Fatal("expected empty cache to report false")
+	}
+}
+
+func TestPop_SkipsExpired(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("old", 1)
+	advance(c, ttl)
+	c.Set("new", 2)
+
+	key, val, ok := c.Pop()
+	if !ok || key != "new" || val != 2 {
+		t.Fatalf("expected the live entry, got %q, %d, %v", key, val, ok)
+	}
+	if _, _, ok := c.Pop(); ok {
+		t.Fatal("expected only expired entries to be left")
+	}
+}
+
 func TestRelease(t *testing.T) {
 	c := newTestCache()
 	defer c.Close()
@@ -118,7 +241,7 @@ func TestRelease_Expired(t *testing.T) {
 	defer c.Close()
 
 	c.Set("a", 1)
-	time.Sleep(ttl + 10*time.Millisecond)
+	advance(c, ttl)
 
 	_, ok := c.Release("a")
 	if ok {
@@ -145,7 +268,7 @@ func TestGetAll_ExcludesExpired(t *testing.T) {
 	defer c.Close()
 
 	c.Set("a", 1)
-	time.Sleep(ttl + 10*time.Millisecond)
+	advance(c, ttl)
 	c.Set("b", 2)
 
 	all := c.GetAll()
@@ -157,6 +280,90 @@ func TestGetAll_ExcludesExpired(t *testing.T) {
 	}
 }
 
+func TestGetAllFunc(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.SetMany(map[string]int{"t1/a": 1, "t1/b": 2, "t2/a": 3})
+	c.Set("t1/old", 4)
+	advance(c, ttl/2)
+	c.Set("t1/c", 5)
+	advance(c, ttl/2)
+
+	got := c.GetAllFunc(func(key string, value int) bool {
+		return strings.HasPrefix(key, "t1/")
+	})
+
+	if want := map[string]int{"t1/c": 5}; !maps.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestGetAllFunc_NoMatch(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+
+	got := c.GetAllFunc(func(key string, value int) bool { return value > 1 })
+	if got == nil || len(got) != 0 {
+		t.Fatalf("expected an empty map, got %v", got)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.SetWithTTL("b", 2, NoExpiration)
+
+	snap := c.Snapshot()
+	if len(snap) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(snap))
+	}
+
+	a := snap["a"]
+	if a.Value != 1 || !a.ExpiryTime.Equal(c.clock.Now().Add(ttl)) {
+		t.Fatalf("unexpected entry for a: %+v", a)
+	}
+	b := snap["b"]
+	if b.Value != 2 || !b.ExpiryTime.IsZero() {
+		t.Fatalf("unexpected entry for b: %+v", b)
+	}
+}
+
+func TestSnapshot_ExcludesExpired(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	advance(c, ttl)
+	c.Set("b", 2)
+
+	snap := c.Snapshot()
+	if len(snap) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(snap))
+	}
+	if _, ok := snap["b"]; !ok {
+		t.Fatal("expected key 'b' to be present")
+	}
+}
+
+func TestSnapshot_IsCopy(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	snap := c.Snapshot()
+	c.Set("a", 2)
+	c.Set("b", 3)
+
+	if len(snap) != 1 || snap["a"].Value != 1 {
+		t.Fatalf("expected snapshot to be unaffected by later writes, got %+v", snap)
+	}
+}
+
 func TestCount(t *testing.T) {
 	c := newTestCache()
 	defer c.Close()
@@ -180,19 +387,84 @@ func TestCount_ExcludesExpired(t *testing.T) {
 	defer c.Close()
 
 	c.Set("a", 1)
-	time.Sleep(ttl + 10*time.Millisecond)
+	advance(c, ttl)
 
 	if n := c.Count(); n != 0 {
 		t.Fatalf("expected count 0, got %d", n)
 	}
 }
 
+func TestCount_DoesNotReap(t *testing.T) {
+	c := newTestCache(WithCleanupInterval[string, int](0))
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	advance(c, ttl)
+	c.Set("c", 3)
+
+	if n := c.Count(); n != 1 {
+		t.Fatalf("expected count 1, got %d", n)
+	}
+
+	c.mu.Lock()
+	n := len(c.items)
+	c.mu.Unlock()
+
+	if n != 3 {
+		t.Fatalf("expected Count to leave expired entries in place, got %d items", n)
+	}
+	if st := c.Stats(); st.Expirations != 0 {
+		t.Fatalf("expected no expirations from Count, got %d", st.Expirations)
+	}
+}
+
+func TestCountLive(t *testing.T) {
+	c := newTestCache(WithCleanupInterval[string, int](0))
+	defer c.Close()
+
+	c.Set("a", 1)
+	advance(c, ttl)
+	c.Set("b", 2)
+
+	if n := c.CountLive(); n != 1 {
+		t.Fatalf("expected 1 live entry, got %d", n)
+	}
+	if n := c.Len(); n != 2 {
+		t.Fatalf("expected CountLive to leave the expired entry in place, got %d entries", n)
+	}
+}
+
+func TestLen(t *testing.T) {
+	c := newTestCache(WithCleanupInterval[string, int](0))
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	advance(c, ttl)
+	c.Set("c", 3)
+
+	if n := c.Len(); n != 3 {
+		t.Fatalf("expected Len to include expired entries, got %d", n)
+	}
+	if n := c.Count(); n != 1 {
+		t.Fatalf("expected count 1, got %d", n)
+	}
+
+	c.DeleteExpired()
+
+	if n := c.Len(); n != 1 {
+		t.Fatalf("expected Len 1 after sweep, got %d", n)
+	}
+}
+
 func TestCleanup(t *testing.T) {
 	c := newTestCache()
 	defer c.Close()
 
 	c.Set("a", 1)
-	time.Sleep(ttl + cleanupInterval + 10*time.Millisecond)
+	advance(c, ttl)
+	time.Sleep(cleanupInterval + 10*time.Millisecond)
 
 	c.mu.Lock()
 	n := len(c.items)
@@ -209,6 +481,108 @@ func TestClose(t *testing.T) {
 	c.Close()
 }
 
+func TestIsClosed(t *testing.T) {
+	c := newTestCache()
+
+	if c.IsClosed() {
+		t.Fatal("expected new cache to be open")
+	}
+
+	c.Close()
+
+	if !c.IsClosed() {
+		t.Fatal("expected cache to be closed")
+	}
+}
+
+func TestSet_AfterClose(t *testing.T) {
+	c := newTestCache()
+	c.Set("a", 1)
+	c.Close()
+
+	c.Set("b", 2)
+
+	if _, ok := c.Get("b"); ok {
+		t.Fatal("expected Set after Close to be a no-op")
+	}
+	if val, ok := c.Get("a"); !ok || val != 1 {
+		t.Fatalf("expected existing key to remain readable, got %d, %v", val, ok)
+	}
+}
+
+func TestClose_Twice(t *testing.T) {
+	c := newTestCache()
+	c.Close()
+	// should not panic
+	c.Close()
+}
+
+func TestClose_WaitsForCleanup(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	c := newTestCache(WithOnEvict(func(key string, value int, reason EvictReason) {
+		if reason == Expired {
+			close(started)
+			<-release
+		}
+	}))
+
+	c.Set("a", 1)
+	advance(c, ttl)
+	<-started
+
+	closed := make(chan struct{})
+	go func() {
+		c.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+		t.Fatal("expected Close to wait for the running sweep")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("expected Close to return once the cleanup goroutine exited")
+	}
+
+	// A second Close must not block either.
+	c.Close()
+}
+
+func TestClose_ContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := NewCacheContext(ctx, WithTTL[string, int](ttl))
+
+	cancel()
+	c.Close()
+}
+
+func TestClose_DroppedCacheStopsCleanup(t *testing.T) {
+	stopped := func() chan struct{} {
+		c := NewCache(WithTTL[string, int](time.Hour))
+		c.Set("a", 1)
+		return c.stopped
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		runtime.GC()
+		select {
+		case <-stopped:
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	t.Fatal("expected the cleanup goroutine to stop once the cache was collected")
+}
+
 func TestConcurrency(t *testing.T) {
 	c := newTestCache()
 	defer c.Close()
@@ -228,3 +602,1056 @@ func TestConcurrency(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestNewCache_CleanupDisabled(t *testing.T) {
+	c := newTestCache(WithCleanupInterval[string, int](0))
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	advance(c, ttl)
+	time.Sleep(cleanupInterval)
+
+	c.mu.Lock()
+	n := len(c.items)
+	c.mu.Unlock()
+
+	if n != 2 {
+		t.Fatalf("expected expired items to stay without cleanup, got %d items", n)
+	}
+
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected expired key to be reported missing")
+	}
+	if n := c.DeleteExpired(); n != 2 {
+		t.Fatalf("expected 2 entries reaped, got %d", n)
+	}
+
+	// should not panic or block without a cleanup goroutine
+	c.Close()
+	c.Close()
+}
+
+func TestSetWithTTL(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.SetWithTTL("short", 1, ttl/2)
+	c.SetWithTTL("long", 2, 3*ttl)
+	advance(c, ttl/2)
+
+	if _, ok := c.Get("short"); ok {
+		t.Fatal("expected short-lived key to be expired")
+	}
+	if val, ok := c.Get("long"); !ok || val != 2 {
+		t.Fatalf("expected long-lived key to exist with 2, got %d, %v", val, ok)
+	}
+}
+
+func TestSetWithTTL_ZeroUsesDefault(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.SetWithTTL("a", 1, 0)
+
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("expected key to exist")
+	}
+
+	advance(c, ttl)
+
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected key to expire after default ttl")
+	}
+}
+
+func TestSetWithTTL_Negative(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.SetWithTTL("a", 2, -time.Second)
+
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected negative ttl to remove the key")
+	}
+}
+
+func TestSetWithTTL_NoExpiration(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.SetWithTTL("forever", 1, NoExpiration)
+	c.Set("transient", 2)
+	advance(c, ttl)
+
+	if val, ok := c.Get("forever"); !ok || val != 1 {
+		t.Fatalf("expected never-expiring key to exist with 1, got %d, %v", val, ok)
+	}
+	if _, ok := c.Get("transient"); ok {
+		t.Fatal("expected transient key to be expired")
+	}
+	if n := c.Count(); n != 1 {
+		t.Fatalf("expected count 1, got %d", n)
+	}
+	if all := c.GetAll(); len(all) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(all))
+	}
+
+	val, ok := c.Release("forever")
+	if !ok || val != 1 {
+		t.Fatalf("expected release to return 1, got %d, %v", val, ok)
+	}
+}
+
+func TestSetDefaultTTL(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	if got := c.TTL(); got != ttl {
+		t.Fatalf("expected ttl %v, got %v", ttl, got)
+	}
+
+	c.Set("old", 1)
+	c.SetDefaultTTL(ttl * 2)
+	c.Set("new", 2)
+
+	if got := c.TTL(); got != ttl*2 {
+		t.Fatalf("expected ttl %v, got %v", ttl*2, got)
+	}
+	if remaining, ok := c.GetTTL("old"); !ok || remaining != ttl {
+		t.Fatalf("expected existing entry to keep its expiry, got %v, %v", remaining, ok)
+	}
+	if remaining, ok := c.GetTTL("new"); !ok || remaining != ttl*2 {
+		t.Fatalf("expected new entry to use the new ttl, got %v, %v", remaining, ok)
+	}
+}
+
+func TestSetDefaultTTL_NonPositive(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.SetDefaultTTL(0)
+	c.Set("a", 1)
+	advance(c, ttl)
+
+	if got := c.TTL(); got != NoExpiration {
+		t.Fatalf("expected no expiration, got %v", got)
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("expected entry stored without a ttl not to expire")
+	}
+}
+func TestGetOrSet(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	val, loaded := c.GetOrSet("a", 1)
+	if loaded {
+		t.Fatal("expected value to be stored, not loaded")
+	}
+	if val != 1 {
+		t.Fatalf("expected 1, got %d", val)
+	}
+
+	val, loaded = c.GetOrSet("a", 2)
+	if !loaded {
+		t.Fatal("expected existing value to be loaded")
+	}
+	if val != 1 {
+		t.Fatalf("expected 1, got %d", val)
+	}
+}
+
+func TestGetOrSet_Expired(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	advance(c, ttl)
+
+	val, loaded := c.GetOrSet("a", 2)
+	if loaded {
+		t.Fatal("expected expired value to be replaced")
+	}
+	if val != 2 {
+		t.Fatalf("expected 2, got %d", val)
+	}
+}
+
+func TestGetOrSet_Concurrent(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		stored int
+	)
+
+	for i := range 50 {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, loaded := c.GetOrSet("a", i); !loaded {
+				mu.Lock()
+				stored++
+				mu.Unlock()
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	if stored != 1 {
+		t.Fatalf("expected exactly one store, got %d", stored)
+	}
+}
+
+func TestSetNX(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	if !c.SetNX("a", 1) {
+		t.Fatal("expected SetNX to store absent key")
+	}
+	if c.SetNX("a", 2) {
+		t.Fatal("expected SetNX to refuse existing key")
+	}
+
+	if val, _ := c.Get("a"); val != 1 {
+		t.Fatalf("expected 1, got %d", val)
+	}
+}
+
+func TestSetNX_Expired(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	advance(c, ttl)
+
+	if !c.SetNX("a", 2) {
+		t.Fatal("expected SetNX to store over expired key")
+	}
+	if val, _ := c.Get("a"); val != 2 {
+		t.Fatalf("expected 2, got %d", val)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	if c.Replace("a", 1) {
+		t.Fatal("expected Replace to refuse missing key")
+	}
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected Replace not to create key")
+	}
+
+	c.Set("a", 1)
+
+	if !c.Replace("a", 2) {
+		t.Fatal("expected Replace to update existing key")
+	}
+	if val, _ := c.Get("a"); val != 2 {
+		t.Fatalf("expected 2, got %d", val)
+	}
+}
+
+func TestReplace_Expired(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	advance(c, ttl)
+
+	if c.Replace("a", 2) {
+		t.Fatal("expected Replace to refuse expired key")
+	}
+}
+
+func TestSetValue_KeepsExpiry(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	if c.SetValue("a", 1) {
+		t.Fatal("expected SetValue to refuse missing key")
+	}
+	if c.Has("a") {
+		t.Fatal("expected SetValue not to create key")
+	}
+
+	c.Set("a", 1)
+	advance(c, ttl/2)
+
+	if !c.SetValue("a", 2) {
+		t.Fatal("expected SetValue to update existing key")
+	}
+	if val, _ := c.Get("a"); val != 2 {
+		t.Fatalf("expected 2, got %d", val)
+	}
+	if remaining, _ := c.GetTTL("a"); remaining != ttl/2 {
+		t.Fatalf("expected the original countdown to continue, got %v", remaining)
+	}
+
+	advance(c, ttl/2)
+	if c.Has("a") {
+		t.Fatal("expected entry to expire at its original time")
+	}
+	if c.SetValue("a", 3) {
+		t.Fatal("expected SetValue to refuse expired key")
+	}
+}
+
+func TestSetValue_NoExpiration(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.SetWithTTL("a", 1, NoExpiration)
+	c.SetValue("a", 2)
+
+	if remaining, _ := c.GetTTL("a"); remaining != NoExpiration {
+		t.Fatalf("expected entry to stay permanent, got %v", remaining)
+	}
+}
+
+func TestHas(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	if c.Has("a") {
+		t.Fatal("expected missing key to be absent")
+	}
+
+	c.Set("a", 1)
+
+	if !c.Has("a") {
+		t.Fatal("expected key to be present")
+	}
+}
+
+func TestHas_Expired(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	advance(c, ttl)
+
+	if c.Has("a") {
+		t.Fatal("expected expired key to be absent")
+	}
+
+	c.mu.Lock()
+	_, ok := c.items["a"]
+	c.mu.Unlock()
+
+	if !ok {
+		t.Fatal("expected Has not to remove the expired key")
+	}
+}
+
+func TestPeek(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+
+	val, ok := c.Peek("a")
+	if !ok || val != 1 {
+		t.Fatalf("expected 1, got %d, %v", val, ok)
+	}
+}
+
+func TestPeek_ExpiredNotDeleted(t *testing.T) {
+	c := newTestCache(WithCleanupInterval[string, int](time.Hour))
+	defer c.Close()
+
+	c.Set("a", 1)
+	advance(c, ttl)
+
+	if _, ok := c.Peek("a"); ok {
+		t.Fatal("expected expired key to be reported as missing")
+	}
+
+	c.mu.Lock()
+	_, ok := c.items["a"]
+	c.mu.Unlock()
+
+	if !ok {
+		t.Fatal("expected Peek not to remove expired key")
+	}
+}
+
+func TestKeys(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	advance(c, ttl)
+	c.Set("b", 2)
+	c.Set("c", 3)
+
+	keys := c.Keys()
+	if len(keys) != 2 {
+		t.Fatalf("expected 2 keys, got %d", len(keys))
+	}
+
+	seen := make(map[string]bool)
+	for _, k := range keys {
+		seen[k] = true
+	}
+	if !seen["b"] || !seen["c"] {
+		t.Fatalf("expected keys b and c, got %v", keys)
+	}
+}
+
+func TestValues(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	advance(c, ttl)
+	c.Set("b", 2)
+
+	values := c.Values()
+	if len(values) != 1 {
+		t.Fatalf("expected 1 value, got %d", len(values))
+	}
+	if values[0] != 2 {
+		t.Fatalf("expected 2, got %d", values[0])
+	}
+}
+
+func TestClear(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Clear()
+
+	if n := c.Count(); n != 0 {
+		t.Fatalf("expected count 0, got %d", n)
+	}
+
+	c.Set("c", 3)
+
+	if val, ok := c.Get("c"); !ok || val != 3 {
+		t.Fatalf("expected cache to remain usable, got %d, %v", val, ok)
+	}
+}
+
+func TestGetTTL(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+
+	remaining, ok := c.GetTTL("a")
+	if !ok {
+		t.Fatal("expected key to exist")
+	}
+	if remaining <= 0 || remaining > ttl {
+		t.Fatalf("expected remaining ttl in (0, %v], got %v", ttl, remaining)
+	}
+
+	c.SetWithTTL("b", 2, NoExpiration)
+
+	remaining, ok = c.GetTTL("b")
+	if !ok || remaining != NoExpiration {
+		t.Fatalf("expected NoExpiration, got %v, %v", remaining, ok)
+	}
+
+	if _, ok := c.GetTTL("missing"); ok {
+		t.Fatal("expected missing key to report false")
+	}
+}
+
+func TestGetTTL_Expired(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	advance(c, ttl)
+
+	if _, ok := c.GetTTL("a"); ok {
+		t.Fatal("expected expired key to report false")
+	}
+}
+
+func TestTouch(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	advance(c, ttl/2)
+
+	if !c.Touch("a") {
+		t.Fatal("expected Touch to succeed on existing key")
+	}
+
+	advance(c, ttl/2)
+
+	if val, ok := c.Get("a"); !ok || val != 1 {
+		t.Fatalf("expected touched key to outlive original ttl, got %d, %v", val, ok)
+	}
+}
+
+func TestTouch_MissingKey(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	if c.Touch("missing") {
+		t.Fatal("expected Touch to fail on missing key")
+	}
+}
+
+func TestExpire(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+
+	if !c.Expire("a", ttl/2) {
+		t.Fatal("expected Expire to succeed on existing key")
+	}
+
+	advance(c, ttl/2)
+
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected key to expire after shortened ttl")
+	}
+}
+
+func TestExpire_NonPositive(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+
+	if c.Expire("a", 0) {
+		t.Fatal("expected Expire with zero ttl to return false")
+	}
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected key to be removed")
+	}
+}
+
+func TestExpire_NonPositiveCountsExpiration(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Expire("a", -time.Second)
+
+	if n := c.Stats().Expirations; n != 1 {
+		t.Fatalf("expected 1 expiration, got %d", n)
+	}
+}
+
+func TestExpire_Closed(t *testing.T) {
+	c := newTestCache()
+	c.Set("a", 1)
+	c.Close()
+
+	c.Expire("a", 0)
+
+	if c.Has("a") {
+		t.Fatal("expected Expire to remove entries after Close like Delete")
+	}
+}
+
+func TestExpire_NoExpirationRemoves(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+
+	if c.Expire("a", NoExpiration) {
+		t.Fatal("expected Expire with a negative ttl to return false")
+	}
+	if c.Has("a") {
+		t.Fatal("expected key to be removed")
+	}
+}
+
+func TestPersist(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+
+	if !c.Persist("a") {
+		t.Fatal("expected Persist to succeed on existing key")
+	}
+
+	advance(c, ttl)
+
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("expected key to never expire")
+	}
+	if c.Persist("missing") {
+		t.Fatal("expected Persist to fail on missing key")
+	}
+}
+
+func TestExpire_MissingKey(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	if c.Expire("missing", ttl) {
+		t.Fatal("expected Expire to fail on missing key")
+	}
+}
+
+func TestExpireAt(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+
+	if !c.ExpireAt("a", c.clock.Now().Add(3*ttl)) {
+		t.Fatal("expected ExpireAt to succeed on existing key")
+	}
+
+	advance(c, ttl)
+
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("expected key to outlive original ttl")
+	}
+}
+
+func TestExpireAt_Past(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+
+	if c.ExpireAt("a", c.clock.Now().Add(-time.Second)) {
+		t.Fatal("expected ExpireAt in the past to return false")
+	}
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected key to be removed")
+	}
+}
+
+func TestExpireAt_PastCountsExpiration(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.ExpireAt("a", c.clock.Now())
+
+	if n := c.Stats().Expirations; n != 1 {
+		t.Fatalf("expected 1 expiration, got %d", n)
+	}
+}
+
+func TestExpireAt_Closed(t *testing.T) {
+	c := newTestCache()
+	c.Set("a", 1)
+	c.Close()
+
+	c.ExpireAt("a", c.clock.Now().Add(-time.Second))
+
+	if c.Has("a") {
+		t.Fatal("expected ExpireAt to remove entries after Close like Delete")
+	}
+}
+
+func TestSetWithExpiry(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.SetWithExpiry("a", 1, c.clock.Now().Add(ttl/2))
+
+	if val, ok := c.Get("a"); !ok || val != 1 {
+		t.Fatalf("expected 1, got %d, %v", val, ok)
+	}
+
+	advance(c, ttl/2)
+
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected key to expire at the given instant")
+	}
+}
+
+func TestSetWithExpiry_Past(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.SetWithExpiry("a", 1, c.clock.Now().Add(-time.Second))
+
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected past expiry not to be stored")
+	}
+}
+
+func TestSlidingTTL(t *testing.T) {
+	c := newTestCache(WithSlidingTTL[string, int]())
+	defer c.Close()
+
+	c.Set("a", 1)
+
+	for range 4 {
+		advance(c, ttl/2)
+		if _, ok := c.Get("a"); !ok {
+			t.Fatal("expected repeated Gets to keep key alive")
+		}
+	}
+
+	advance(c, ttl)
+
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected key to expire once reads stop")
+	}
+}
+
+func TestSlidingTTL_DisabledByDefault(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	advance(c, ttl/2)
+	c.Get("a")
+	advance(c, ttl/2)
+
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected Get not to extend ttl by default")
+	}
+}
+
+func BenchmarkGet_Parallel(b *testing.B) {
+	c := NewCache[int, int]()
+	defer c.Close()
+
+	for i := range 1024 {
+		c.Set(i, i)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			c.Get(i & 1023)
+			i++
+		}
+	})
+}
+
+func TestDeleteFunc(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("c", 3)
+	c.Set("d", 4)
+
+	n := c.DeleteFunc(func(_ string, v int) bool {
+		return v%2 == 0
+	})
+	if n != 2 {
+		t.Fatalf("expected 2 deletions, got %d", n)
+	}
+
+	if _, ok := c.Get("b"); ok {
+		t.Fatal("expected key b to be deleted")
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("expected key a to remain")
+	}
+	if n := c.Count(); n != 2 {
+		t.Fatalf("expected count 2, got %d", n)
+	}
+}
+
+func TestRange(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	advance(c, ttl)
+	c.Set("b", 2)
+	c.Set("c", 3)
+
+	seen := make(map[string]int)
+	c.Range(func(k string, v int) bool {
+		seen[k] = v
+		return true
+	})
+
+	if len(seen) != 2 || seen["b"] != 2 || seen["c"] != 3 {
+		t.Fatalf("expected b and c, got %v", seen)
+	}
+}
+
+func TestRange_StopsEarly(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("c", 3)
+
+	calls := 0
+	c.Range(func(string, int) bool {
+		calls++
+		return false
+	})
+
+	if calls != 1 {
+		t.Fatalf("expected 1 call, got %d", calls)
+	}
+}
+
+func TestSetMany(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.SetMany(map[string]int{"a": 1, "b": 2, "c": 3})
+
+	if n := c.Count(); n != 3 {
+		t.Fatalf("expected count 3, got %d", n)
+	}
+	if val, ok := c.Get("b"); !ok || val != 2 {
+		t.Fatalf("expected 2, got %d, %v", val, ok)
+	}
+
+	advance(c, ttl)
+
+	if n := c.Count(); n != 0 {
+		t.Fatalf("expected entries to expire, got count %d", n)
+	}
+}
+
+func TestSetManyWithTTL(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.SetManyWithTTL(map[string]int{"a": 1, "b": 2}, NoExpiration)
+	advance(c, ttl)
+
+	if n := c.Count(); n != 2 {
+		t.Fatalf("expected count 2, got %d", n)
+	}
+}
+
+func TestSetManyEvicting(t *testing.T) {
+	rec := &evictRecorder{}
+	c := newTestCache(WithMaxEntries[string, int](3), WithOnEvict(rec.record))
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("c", 3)
+	c.Get("a")
+
+	evicted := c.SetManyEvicting(map[string]int{"a": 10, "d": 4, "e": 5})
+	if !slices.Equal(evicted, []string{"b", "c"}) {
+		t.Fatalf("expected b and c to be evicted in LRU order, got %v", evicted)
+	}
+	if !c.Has("a") || !c.Has("d") || !c.Has("e") {
+		t.Fatal("expected the batch to be stored")
+	}
+	if st := c.Stats(); st.Evictions != 2 {
+		t.Fatalf("expected 2 evictions, got %d", st.Evictions)
+	}
+	var fired []string
+	for _, r := range rec.all() {
+		if r.reason == Evicted {
+			fired = append(fired, r.key)
+		}
+	}
+	if !slices.Equal(fired, evicted) {
+		t.Fatalf("expected OnEvict to fire for %v, got %v", evicted, fired)
+	}
+
+	if evicted := c.SetManyEvicting(map[string]int{"a": 1}); evicted != nil {
+		t.Fatalf("expected no evictions, got %v", evicted)
+	}
+	c.Set("f", 6)
+	if n := c.Stats().Evictions; n != 3 {
+		t.Fatalf("expected later evictions to be counted as usual, got %d", n)
+	}
+}
+
+func TestSetManyStaggered(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	items := map[string]int{"a": 1, "b": 2, "c": 3, "d": 4}
+	spread := 40 * time.Millisecond
+	c.SetManyStaggered(items, spread)
+
+	now := c.clock.Now()
+	seen := map[time.Duration]bool{}
+
+	for k := range items {
+		offset := c.items[k].expiryTime.Sub(now) - ttl
+		if offset < 0 || offset >= spread || offset%(spread/4) != 0 {
+			t.Fatalf("unexpected expiry offset %v for %q", offset, k)
+		}
+		seen[offset] = true
+	}
+	if len(seen) != len(items) {
+		t.Fatalf("expected every entry in its own slot, got %v", seen)
+	}
+
+	advance(c, ttl)
+	if n := c.Count(); n != 3 {
+		t.Fatalf("expected only the first slot to expire at ttl, got count %d", n)
+	}
+
+	advance(c, spread)
+	if n := c.Count(); n != 0 {
+		t.Fatalf("expected all entries to expire by ttl+spread, got count %d", n)
+	}
+}
+
+func TestSetManyStaggered_NoSpread(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.SetManyStaggered(map[string]int{"a": 1, "b": 2}, 0)
+
+	want := c.clock.Now().Add(ttl)
+	for _, k := range []string{"a", "b"} {
+		if exp := c.items[k].expiryTime; !exp.Equal(want) {
+			t.Fatalf("expected plain ttl expiry for %q, got %v", k, exp)
+		}
+	}
+}
+
+func TestSetManyStaggered_Empty(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.SetManyStaggered(map[string]int{}, time.Second)
+
+	if n := c.Len(); n != 0 {
+		t.Fatalf("expected an empty batch to store nothing, got %d entries", n)
+	}
+}
+
+func TestSetManyStaggered_NoExpiration(t *testing.T) {
+	c := NewCache[string, int]()
+	defer c.Close()
+
+	c.SetManyStaggered(map[string]int{"a": 1}, time.Minute)
+
+	if ttl, _ := c.GetTTL("a"); ttl != NoExpiration {
+		t.Fatalf("expected entry to never expire, got %v", ttl)
+	}
+}
+
+func TestGetMany(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	advance(c, ttl)
+	c.Set("b", 2)
+	c.Set("c", 3)
+
+	got := c.GetMany([]string{"a", "b", "c", "missing"})
+	if len(got) != 2 {
+		t.Fatalf("expected 2 items, got %v", got)
+	}
+	if got["b"] != 2 || got["c"] != 3 {
+		t.Fatalf("expected b=2 and c=3, got %v", got)
+	}
+	if st := c.Stats(); st.Hits != 2 || st.Misses != 2 {
+		t.Fatalf("expected 2 hits and 2 misses, got %+v", st)
+	}
+}
+
+func TestGetManyOrDefault(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	advance(c, ttl)
+	c.Set("b", 2)
+
+	got := c.GetManyOrDefault([]string{"a", "b", "missing"}, -1)
+	if !maps.Equal(got, map[string]int{"a": -1, "b": 2, "missing": -1}) {
+		t.Fatalf("expected defaults for absent keys, got %v", got)
+	}
+	if c.Has("missing") {
+		t.Fatal("expected defaults not to be stored")
+	}
+	if st := c.Stats(); st.Hits != 1 || st.Misses != 2 {
+		t.Fatalf("expected 1 hit and 2 misses, got %+v", st)
+	}
+}
+
+func TestDeleteMany(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("c", 3)
+
+	if n := c.DeleteMany([]string{"a", "b", "missing"}); n != 2 {
+		t.Fatalf("expected 2 deletions, got %d", n)
+	}
+	if n := c.Count(); n != 1 {
+		t.Fatalf("expected count 1, got %d", n)
+	}
+	if _, ok := c.Get("c"); !ok {
+		t.Fatal("expected key c to remain")
+	}
+}
+
+func TestDeleteExpired(t *testing.T) {
+	c := newTestCache(WithCleanupInterval[string, int](time.Hour))
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.SetWithTTL("c", 3, NoExpiration)
+	advance(c, ttl)
+
+	if n := c.DeleteExpired(); n != 2 {
+		t.Fatalf("expected 2 entries reaped, got %d", n)
+	}
+
+	c.mu.Lock()
+	n := len(c.items)
+	c.mu.Unlock()
+
+	if n != 1 {
+		t.Fatalf("expected 1 remaining item, got %d", n)
+	}
+}
+
+func TestNewCacheContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := NewCacheContext(ctx,
+		WithTTL[string, int](ttl),
+		WithCleanupInterval[string, int](cleanupInterval),
+		WithClock[string, int](newFakeClock()),
+	)
+
+	cancel()
+	// give the cleanup goroutine time to observe the cancellation
+	time.Sleep(10 * time.Millisecond)
+
+	c.Set("a", 1)
+	advance(c, ttl)
+	time.Sleep(cleanupInterval + 10*time.Millisecond)
+
+	c.mu.Lock()
+	n := len(c.items)
+	c.mu.Unlock()
+
+	if n != 1 {
+		t.Fatalf("expected cleanup to stop after cancellation, got %d items", n)
+	}
+
+	// should not panic after the context is canceled
+	c.Close()
+}
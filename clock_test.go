@@ -0,0 +1,68 @@
+package mcache
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+func (f *fakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+}
+
+func advance[K comparable, V any](c *Cache[K, V], d time.Duration) {
+	c.clock.(*fakeClock).Advance(d)
+}
+
+func TestWithClock(t *testing.T) {
+	clock := newFakeClock()
+	c := NewCache(WithTTL[string, int](ttl), WithClock[string, int](clock))
+	defer c.Close()
+
+	c.Set("a", 1)
+
+	remaining, _ := c.GetTTL("a")
+	if remaining != ttl {
+		t.Fatalf("expected remaining ttl %v on a frozen clock, got %v", ttl, remaining)
+	}
+
+	clock.Advance(ttl - time.Nanosecond)
+
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("expected key to exist just before expiry")
+	}
+
+	clock.Advance(time.Nanosecond)
+
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected key to expire exactly at ttl")
+	}
+}
+
+func TestRealClockIsDefault(t *testing.T) {
+	c := newTestCacheRealClock()
+	defer c.Close()
+
+	if _, ok := c.clock.(realClock); !ok {
+		t.Fatalf("expected real clock by default, got %T", c.clock)
+	}
+}
@@ -0,0 +1,83 @@
+package mcache
+
+func (c *Cache[K, V]) Clone() *Cache[K, V] {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	opts := []Option[K, V]{
+		WithTTL[K, V](c.ttl),
+		WithCleanupInterval[K, V](c.cleanupInterval),
+		WithCleanupBatch[K, V](c.cleanupBatch),
+		WithGracePeriod[K, V](c.grace),
+		WithMaxEntries[K, V](c.maxEntries),
+		WithMaxCost[K, V](c.maxCost),
+		WithCoster(c.coster),
+		WithEvictionPolicy[K, V](c.policy),
+		WithOnEvict(c.onEvict),
+		WithClock[K, V](c.clock),
+		WithComputeHook[K, V](c.computeHook),
+		WithOnLoadError[K, V](c.onLoadError),
+		WithLoadStripes[K, V](len(c.stripes)),
+		WithMaxConcurrentLoads[K, V](cap(c.loadSlots)),
+		WithJitter[K, V](c.jitter),
+		WithEventBuffer[K, V](c.eventBuffer),
+	}
+	if c.slidingTTL {
+		opts = append(opts, WithSlidingTTL[K, V]())
+	}
+
+	clone := NewCache(opts...)
+	now := c.clock.Now()
+
+	// The clone's cleanup goroutine is already running.
+	clone.mu.Lock()
+	defer clone.mu.Unlock()
+
+	clone.versions = c.versions
+
+	copyItem := func(key K, it *item[K, V]) {
+		if it.expired(now) {
+			return
+		}
+
+		cp := &item[K, V]{
+			key:     key,
+			value:   it.value,
+			cost:    it.cost,
+			version: it.version,
+			index:   -1,
+			created: it.created,
+		}
+		clone.setExpiryTime(cp, it.expiryTime)
+		cp.accessed.Store(it.accessed.Load())
+		cp.hits.Store(it.hits.Load())
+		clone.track(cp)
+
+		clone.items[key] = cp
+		clone.cost += cp.cost
+	}
+
+	if c.ordered() {
+		// Walk oldest to newest so the clone evicts in the same order.
+		for e := c.order.Back(); e != nil; e = e.Prev() {
+			key := e.Value.(K)
+			copyItem(key, c.items[key])
+		}
+	} else {
+		for k, it := range c.items {
+			copyItem(k, it)
+		}
+	}
+
+	return clone
+}
+
+func (c *Cache[K, V]) Merge(other *Cache[K, V], overwrite bool) {
+	if other == c {
+		return
+	}
+
+	// Reading other before locking c means the two locks are never held
+	// together, so concurrent merges in opposite directions cannot deadlock.
+	c.restore(other.records(), overwrite)
+}
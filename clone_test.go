@@ -0,0 +1,263 @@
+package mcache
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClone(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.SetWithTTL("b", 2, NoExpiration)
+
+	clone := c.Clone()
+	defer clone.Close()
+
+	if val, ok := clone.Get("a"); !ok || val != 1 {
+		t.Fatalf("expected a=1, got %d, %v", val, ok)
+	}
+	if ttl, _ := clone.GetTTL("b"); ttl != NoExpiration {
+		t.Fatalf("expected b to never expire, got %v", ttl)
+	}
+	if clone.ttl != c.ttl || clone.cleanupInterval != c.cleanupInterval {
+		t.Fatal("expected clone to keep ttl and cleanup settings")
+	}
+}
+
+func TestClone_PreservesExpiry(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	advance(c, ttl/2)
+
+	clone := c.Clone()
+	defer clone.Close()
+
+	if remaining, ok := clone.GetTTL("a"); !ok || remaining != ttl/2 {
+		t.Fatalf("expected remaining ttl %v, got %v, %v", ttl/2, remaining, ok)
+	}
+
+	advance(c, ttl/2)
+	if clone.Has("a") {
+		t.Fatal("expected cloned entry to expire at the original time")
+	}
+}
+
+func TestClone_ExcludesExpired(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	advance(c, ttl)
+
+	clone := c.Clone()
+	defer clone.Close()
+
+	if n := len(clone.items); n != 0 {
+		t.Fatalf("expected expired entries to be skipped, got %d", n)
+	}
+}
+
+func TestClone_Independent(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+
+	clone := c.Clone()
+	defer clone.Close()
+
+	clone.Set("a", 10)
+	clone.Set("b", 2)
+	c.Delete("a")
+
+	if c.Has("b") {
+		t.Fatal("expected writes to the clone not to affect the original")
+	}
+	if val, ok := clone.Get("a"); !ok || val != 10 {
+		t.Fatalf("expected deletes on the original not to affect the clone, got %d, %v", val, ok)
+	}
+
+	c.Close()
+	if clone.IsClosed() {
+		t.Fatal("expected closing the original not to close the clone")
+	}
+}
+
+func TestClone_PreservesEvictionOrder(t *testing.T) {
+	c := newBoundedTestCache(3, WithClock[string, int](newFakeClock()))
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("c", 3)
+	c.Get("a")
+
+	clone := c.Clone()
+	defer clone.Close()
+
+	clone.Set("d", 4)
+
+	if clone.Has("b") {
+		t.Fatal("expected least recently used key 'b' to be evicted from the clone")
+	}
+	for _, k := range []string{"a", "c", "d"} {
+		if !clone.Has(k) {
+			t.Fatalf("expected key %q to remain in the clone", k)
+		}
+	}
+	if !c.Has("b") {
+		t.Fatal("expected eviction in the clone not to affect the original")
+	}
+}
+
+func TestClone_SharesOnEvict(t *testing.T) {
+	rec := &evictRecorder{}
+	c := NewCache(WithTTL[string, int](time.Hour), WithOnEvict(rec.record))
+	defer c.Close()
+
+	c.Set("a", 1)
+
+	clone := c.Clone()
+	defer clone.Close()
+
+	clone.Delete("a")
+
+	records := rec.all()
+	if len(records) != 1 || records[0].key != "a" || records[0].reason != Deleted {
+		t.Fatalf("expected one Deleted event from the clone, got %+v", records)
+	}
+}
+
+func TestMerge_Overwrite(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+	other := newTestCache()
+	defer other.Close()
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	other.Set("b", 20)
+	other.Set("c", 30)
+
+	c.Merge(other, true)
+
+	want := map[string]int{"a": 1, "b": 20, "c": 30}
+	got := c.GetAll()
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestMerge_NoOverwrite(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+	other := newTestCache()
+	defer other.Close()
+
+	c.Set("a", 1)
+	other.Set("a", 10)
+	other.Set("b", 20)
+
+	c.Merge(other, false)
+
+	if val, _ := c.Get("a"); val != 1 {
+		t.Fatalf("expected existing a=1 to be kept, got %d", val)
+	}
+	if val, _ := c.Get("b"); val != 20 {
+		t.Fatalf("expected b=20 to be merged, got %d", val)
+	}
+}
+
+func TestMerge_NoOverwriteReplacesExpired(t *testing.T) {
+	clock := newFakeClock()
+	c := newTestCache(WithClock[string, int](clock))
+	defer c.Close()
+	other := newTestCache(WithClock[string, int](clock))
+	defer other.Close()
+
+	c.Set("a", 1)
+	advance(c, ttl)
+	other.Set("a", 10)
+
+	c.Merge(other, false)
+
+	if val, ok := c.Get("a"); !ok || val != 10 {
+		t.Fatalf("expected expired entry to be replaced, got %d, %v", val, ok)
+	}
+}
+
+func TestMerge_PreservesExpiryAndSkipsExpired(t *testing.T) {
+	clock := newFakeClock()
+	c := newTestCache(WithClock[string, int](clock))
+	defer c.Close()
+	other := newTestCache(WithClock[string, int](clock))
+	defer other.Close()
+
+	other.Set("old", 1)
+	advance(other, ttl/2)
+	other.Set("new", 2)
+	other.SetWithTTL("expired", 3, ttl/4)
+	advance(other, ttl/4)
+
+	c.Merge(other, true)
+
+	if c.Has("expired") {
+		t.Fatal("expected entries expired in other to be skipped")
+	}
+	if remaining, ok := c.GetTTL("old"); !ok || remaining != ttl/4 {
+		t.Fatalf("expected old to keep its expiry, got %v, %v", remaining, ok)
+	}
+	if remaining, ok := c.GetTTL("new"); !ok || remaining != ttl*3/4 {
+		t.Fatalf("expected new to keep its expiry, got %v, %v", remaining, ok)
+	}
+}
+
+func TestMerge_Self(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Merge(c, true)
+
+	if val, ok := c.Get("a"); !ok || val != 1 {
+		t.Fatalf("expected merging into itself to be a no-op, got %d, %v", val, ok)
+	}
+}
+
+func TestMerge_ConcurrentOppositeDirections(t *testing.T) {
+	a := newTestCache()
+	defer a.Close()
+	b := newTestCache()
+	defer b.Close()
+
+	a.Set("a", 1)
+	b.Set("b", 2)
+
+	var wg sync.WaitGroup
+	for range 100 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			a.Merge(b, false)
+		}()
+		go func() {
+			defer wg.Done()
+			b.Merge(a, false)
+		}()
+	}
+	wg.Wait()
+
+	if !a.Has("b") || !b.Has("a") {
+		t.Fatal("expected both caches to contain all keys")
+	}
+}
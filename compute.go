@@ -0,0 +1,221 @@
+package mcache
+
+import (
+	"context"
+	"fmt"
+	"hash/maphash"
+	"sync"
+)
+
+type ComputeHook[K comparable] func(ctx context.Context, key K) (context.Context, func(hit bool, err error))
+
+type call[V any] struct {
+	done    chan struct{}
+	value   V
+	err     error
+	cached  bool
+	waiters int
+	cancel  context.CancelFunc
+}
+
+const defaultLoadStripes = 16
+
+// panicError carries a loader panic from the load goroutine to the callers
+// waiting for it, which re-raise the original value.
+type panicError struct {
+	value any
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("mcache: loader panicked: %v", e.value)
+}
+
+// loadStripe holds the in-flight loads for the keys that hash to it, so
+// registering a load only contends with callers of keys in the same stripe.
+type loadStripe[K comparable, V any] struct {
+	mu    sync.Mutex
+	calls map[K]*call[V]
+}
+
+func newLoadStripes[K comparable, V any](n int) []loadStripe[K, V] {
+	stripes := make([]loadStripe[K, V], max(n, 1))
+	for i := range stripes {
+		stripes[i].calls = make(map[K]*call[V])
+	}
+	return stripes
+}
+
+func (c *Cache[K, V]) stripe(key K) *loadStripe[K, V] {
+	if len(c.stripes) == 1 {
+		return &c.stripes[0]
+	}
+	return &c.stripes[maphash.Comparable(c.stripeSeed, key)%uint64(len(c.stripes))]
+}
+
+func (c *Cache[K, V]) GetOrCompute(key K, loader func() (V, error)) (V, error) {
+	return c.GetOrComputeCtx(context.Background(), key, func(context.Context) (V, error) {
+		return loader()
+	})
+}
+
+func (c *Cache[K, V]) GetOrComputeCtx(ctx context.Context, key K, loader func(ctx context.Context) (V, error)) (V, error) {
+	if c.computeHook == nil {
+		val, _, err := c.getOrCompute(ctx, key, loader)
+		return val, err
+	}
+
+	ctx, done := c.computeHook(ctx, key)
+	val, hit, err := c.getOrCompute(ctx, key, loader)
+	done(hit, err)
+
+	return val, err
+}
+
+func (c *Cache[K, V]) GetOrSetFunc(key K, fn func() V) (V, bool) {
+	val, hit, _ := c.getOrCompute(context.Background(), key, func(context.Context) (V, error) {
+		return fn(), nil
+	})
+	return val, hit
+}
+
+// getOrCompute reports hit as true when the value was not loaded by this
+// call, either because it was cached or because a concurrent load shared it.
+//
+// The load runs in its own goroutine with a context that keeps the values of
+// the caller that started it but not its cancellation: every caller waits on
+// its own ctx, and the load is canceled only once all of them have given up.
+func (c *Cache[K, V]) getOrCompute(ctx context.Context, key K, loader func(ctx context.Context) (V, error)) (V, bool, error) {
+	if val, ok := c.Get(key); ok {
+		return val, true, nil
+	}
+
+	if err := ctx.Err(); err != nil {
+		var zero V
+		return zero, false, err
+	}
+
+	st := c.stripe(key)
+	st.mu.Lock()
+	cl, shared := st.calls[key]
+	if shared {
+		c.stats.loadsDeduplicated.Add(1)
+	} else {
+		loadCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		cl = &call[V]{done: make(chan struct{}), cancel: cancel}
+		st.calls[key] = cl
+		c.stats.loadsInitiated.Add(1)
+		go c.load(loadCtx, key, cl, loader)
+	}
+	cl.waiters++
+	st.mu.Unlock()
+
+	select {
+	case <-cl.done:
+		if pe, ok := cl.err.(*panicError); ok {
+			panic(pe.value)
+		}
+		return cl.value, shared || cl.cached, cl.err
+	case <-ctx.Done():
+		c.leave(key, cl)
+		var zero V
+		return zero, false, ctx.Err()
+	}
+}
+
+// refresh starts a load for key in the background unless one is already in
+// flight. The refresh counts as a waiter itself, so callers that join it and
+// then give up never cancel it.
+func (c *Cache[K, V]) refresh(key K, loader func(ctx context.Context) (V, error)) {
+	st := c.stripe(key)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if _, ok := st.calls[key]; ok {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cl := &call[V]{done: make(chan struct{}), cancel: cancel, waiters: 1}
+	st.calls[key] = cl
+	c.stats.loadsInitiated.Add(1)
+	go c.load(ctx, key, cl, loader)
+}
+
+func (c *Cache[K, V]) load(ctx context.Context, key K, cl *call[V], loader func(ctx context.Context) (V, error)) {
+	defer func() {
+		st := c.stripe(key)
+		st.mu.Lock()
+		if st.calls[key] == cl {
+			delete(st.calls, key)
+		}
+		st.mu.Unlock()
+		cl.cancel()
+		close(cl.done)
+	}()
+
+	// A load that finished between the miss and registering this call has
+	// already stored its result.
+	if val, ok := c.get(key); ok {
+		cl.value, cl.cached = val, true
+		return
+	}
+
+	if c.loadSlots != nil {
+		select {
+		case c.loadSlots <- struct{}{}:
+			defer func() { <-c.loadSlots }()
+		case <-ctx.Done():
+			cl.err = ctx.Err()
+			return
+		}
+	}
+
+	// Every caller may have left before the load got going.
+	if err := ctx.Err(); err != nil {
+		cl.err = err
+		return
+	}
+
+	// An abandoned load may race a fresh one for the same key, so only a
+	// load that still has waiters stores its result, and only if nothing
+	// was written to the key while the loader ran.
+	cl.value, cl.err = callLoader(ctx, loader)
+	switch {
+	case ctx.Err() != nil:
+	case cl.err != nil:
+		c.stats.loadErrors.Add(1)
+		if c.onLoadError != nil {
+			c.onLoadError(key, cl.err)
+		}
+	default:
+		c.setIfAbsent(key, cl.value, 0, nil)
+	}
+}
+
+// callLoader turns a loader panic into a panicError, since nothing above the
+// load goroutine could recover it.
+func callLoader[V any](ctx context.Context, loader func(ctx context.Context) (V, error)) (val V, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &panicError{value: r}
+		}
+	}()
+
+	return loader(ctx)
+}
+
+// leave cancels an abandoned load and unregisters it, so later callers start
+// a fresh load instead of joining one that is being torn down.
+func (c *Cache[K, V]) leave(key K, cl *call[V]) {
+	st := c.stripe(key)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	cl.waiters--
+	if cl.waiters == 0 {
+		cl.cancel()
+		if st.calls[key] == cl {
+			delete(st.calls, key)
+		}
+	}
+}
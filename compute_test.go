@@ -0,0 +1,641 @@
+package mcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetOrCompute(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	calls := 0
+	loader := func() (int, error) {
+		calls++
+		return 7, nil
+	}
+
+	val, err := c.GetOrCompute("a", loader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if val != 7 {
+		t.Fatalf("expected 7, got %d", val)
+	}
+
+	val, err = c.GetOrCompute("a", loader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if val != 7 {
+		t.Fatalf("expected 7, got %d", val)
+	}
+	if calls != 1 {
+		t.Fatalf("expected loader to run once, ran %d times", calls)
+	}
+}
+
+func TestGetOrCompute_Error(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	errLoad := errors.New("load failed")
+
+	_, err := c.GetOrCompute("a", func() (int, error) {
+		return 0, errLoad
+	})
+	if !errors.Is(err, errLoad) {
+		t.Fatalf("expected %v, got %v", errLoad, err)
+	}
+
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected failed load not to be cached")
+	}
+	if st := c.Stats(); st.LoadErrors != 1 {
+		t.Fatalf("expected 1 load error, got %d", st.LoadErrors)
+	}
+}
+
+func TestGetOrCompute_KeepsConcurrentSet(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan int)
+	go func() {
+		val, _ := c.GetOrCompute("a", func() (int, error) {
+			close(started)
+			<-release
+			return 1, nil
+		})
+		done <- val
+	}()
+
+	<-started
+	c.Set("a", 2)
+	close(release)
+
+	if val := <-done; val != 1 {
+		t.Fatalf("expected the caller to get the loaded value, got %d", val)
+	}
+	if val, _ := c.Get("a"); val != 2 {
+		t.Fatalf("expected the concurrent Set to win, got %d", val)
+	}
+}
+
+func TestGetOrCompute_LoaderPanic(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	release := make(chan struct{})
+	loader := func() (int, error) {
+		<-release
+		panic("boom")
+	}
+
+	recovered := make(chan any, 2)
+	for range 2 {
+		go func() {
+			defer func() { recovered <- recover() }()
+			c.GetOrCompute("a", loader)
+		}()
+	}
+
+	waitForWaiters(t, c, "a", 2)
+	close(release)
+
+	for range 2 {
+		if r := <-recovered; r != "boom" {
+			t.Fatalf("expected every caller to re-raise the loader panic, got %v", r)
+		}
+	}
+
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected a panicking load not to be cached")
+	}
+	if val, err := c.GetOrCompute("a", func() (int, error) { return 1, nil }); err != nil || val != 1 {
+		t.Fatalf("expected a later load to succeed, got %d, %v", val, err)
+	}
+}
+
+func TestGetOrCompute_Deduplicates(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	var (
+		calls   atomic.Int32
+		release = make(chan struct{})
+		wg      sync.WaitGroup
+	)
+
+	loader := func() (int, error) {
+		calls.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			val, err := c.GetOrCompute("a", loader)
+			if err != nil || val != 42 {
+				t.Errorf("expected 42, got %d, %v", val, err)
+			}
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("expected loader to run once, ran %d times", n)
+	}
+}
+
+func TestGetOrSetFunc(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	calls := 0
+	fn := func() int {
+		calls++
+		return 7
+	}
+
+	if val, ok := c.GetOrSetFunc("a", fn); ok || val != 7 {
+		t.Fatalf("expected to compute 7, got %d, %v", val, ok)
+	}
+	if val, ok := c.GetOrSetFunc("a", fn); !ok || val != 7 {
+		t.Fatalf("expected existing 7, got %d, %v", val, ok)
+	}
+	if calls != 1 {
+		t.Fatalf("expected fn to run once, ran %d times", calls)
+	}
+	if remaining, ok := c.GetTTL("a"); !ok || remaining != ttl {
+		t.Fatalf("expected computed value to get the default ttl, got %v, %v", remaining, ok)
+	}
+}
+
+func TestGetOrSetFunc_Deduplicates(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	var (
+		calls    atomic.Int32
+		computed atomic.Int32
+		release  = make(chan struct{})
+		wg       sync.WaitGroup
+	)
+
+	fn := func() int {
+		calls.Add(1)
+		<-release
+		return 42
+	}
+
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			val, ok := c.GetOrSetFunc("a", fn)
+			if val != 42 {
+				t.Errorf("expected 42, got %d", val)
+			}
+			if !ok {
+				computed.Add(1)
+			}
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("expected fn to run once, ran %d times", n)
+	}
+	if n := computed.Load(); n != 1 {
+		t.Fatalf("expected a single caller to report computing the value, got %d", n)
+	}
+}
+
+func TestGetOrCompute_DoesNotBlockOtherKeys(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	go c.GetOrCompute("slow", func() (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+
+	<-started
+	defer close(release)
+
+	c.Set("b", 2)
+	if val, ok := c.Get("b"); !ok || val != 2 {
+		t.Fatalf("expected 2, got %d, %v", val, ok)
+	}
+}
+
+func TestWithLoadStripes(t *testing.T) {
+	for _, tc := range []struct{ n, want int }{{0, 1}, {1, 1}, {64, 64}} {
+		c := newTestCache(WithLoadStripes[string, int](tc.n))
+		if len(c.stripes) != tc.want {
+			t.Fatalf("WithLoadStripes(%d): expected %d stripes, got %d", tc.n, tc.want, len(c.stripes))
+		}
+		c.Close()
+	}
+
+	c := newTestCache()
+	defer c.Close()
+	if len(c.stripes) != defaultLoadStripes {
+		t.Fatalf("expected %d stripes by default, got %d", defaultLoadStripes, len(c.stripes))
+	}
+}
+
+func TestGetOrCompute_StripedConcurrency(t *testing.T) {
+	for _, stripes := range []int{1, 4} {
+		c := newTestCache(WithLoadStripes[string, int](stripes))
+
+		var (
+			calls atomic.Int32
+			wg    sync.WaitGroup
+		)
+		for i := range 200 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				key := strconv.Itoa(i % 20)
+				val, err := c.GetOrCompute(key, func() (int, error) {
+					calls.Add(1)
+					time.Sleep(time.Millisecond)
+					return i % 20, nil
+				})
+				if err != nil || strconv.Itoa(val) != key {
+					t.Errorf("expected %s, got %d, %v", key, val, err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if n := calls.Load(); n != 20 {
+			t.Fatalf("expected one load per key, got %d", n)
+		}
+		for i := range c.stripes {
+			if n := len(c.stripes[i].calls); n != 0 {
+				t.Fatalf("expected no loads to remain registered, got %d", n)
+			}
+		}
+
+		c.Close()
+	}
+}
+
+func TestWithMaxConcurrentLoads(t *testing.T) {
+	const limit = 2
+
+	c := newTestCache(WithMaxConcurrentLoads[string, int](limit))
+	defer c.Close()
+
+	var (
+		running, peak atomic.Int32
+		wg            sync.WaitGroup
+	)
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.GetOrCompute(strconv.Itoa(i), func() (int, error) {
+				n := running.Add(1)
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				running.Add(-1)
+				return i, nil
+			})
+		}()
+	}
+	wg.Wait()
+
+	if n := peak.Load(); n > limit {
+		t.Fatalf("expected at most %d concurrent loads, got %d", limit, n)
+	}
+	if n := c.Count(); n != 10 {
+		t.Fatalf("expected queued loads to complete, got %d entries", n)
+	}
+}
+
+func TestWithMaxConcurrentLoads_QueuedCallerDeadline(t *testing.T) {
+	c := newTestCache(WithMaxConcurrentLoads[string, int](1))
+	defer c.Close()
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go c.GetOrCompute("slow", func() (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := c.GetOrComputeCtx(ctx, "queued", func(ctx context.Context) (int, error) {
+		t.Error("expected the queued loader not to run")
+		return 0, nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a queued caller to return on its deadline, got %v", err)
+	}
+}
+
+func BenchmarkGetOrCompute_SlowLoader(b *testing.B) {
+	for _, stripes := range []int{1, defaultLoadStripes} {
+		b.Run(fmt.Sprintf("stripes=%d", stripes), func(b *testing.B) {
+			c := NewCache(WithLoadStripes[int, int](stripes), WithMaxEntries[int, int](1024))
+			defer c.Close()
+
+			// A load that never finishes during the benchmark must not slow
+			// down loads of unrelated keys.
+			release := make(chan struct{})
+			defer close(release)
+			go c.GetOrCompute(-1, func() (int, error) {
+				<-release
+				return 0, nil
+			})
+
+			var next atomic.Int64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					key := int(next.Add(1))
+					c.GetOrCompute(key, func() (int, error) {
+						return key, nil
+					})
+				}
+			})
+		})
+	}
+}
+
+type computeRecord struct {
+	key string
+	hit bool
+	err error
+}
+
+func TestGetOrCompute_Hook(t *testing.T) {
+	var records []computeRecord
+	hook := func(ctx context.Context, key string) (context.Context, func(hit bool, err error)) {
+		return ctx, func(hit bool, err error) {
+			records = append(records, computeRecord{key: key, hit: hit, err: err})
+		}
+	}
+
+	c := newTestCache(WithComputeHook[string, int](hook))
+	defer c.Close()
+
+	errLoad := errors.New("load failed")
+
+	c.GetOrCompute("a", func() (int, error) { return 1, nil })
+	c.GetOrCompute("a", func() (int, error) { return 2, nil })
+	c.GetOrCompute("b", func() (int, error) { return 0, errLoad })
+
+	want := []computeRecord{
+		{key: "a", hit: false},
+		{key: "a", hit: true},
+		{key: "b", hit: false, err: errLoad},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("expected %d hook calls, got %d", len(want), len(records))
+	}
+	for i, r := range want {
+		if records[i] != r {
+			t.Fatalf("hook call %d: expected %+v, got %+v", i, r, records[i])
+		}
+	}
+}
+
+type ctxKey struct{}
+
+func TestGetOrComputeCtx(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "request")
+
+	val, err := c.GetOrComputeCtx(ctx, "a", func(ctx context.Context) (int, error) {
+		if ctx.Value(ctxKey{}) != "request" {
+			t.Error("expected the loader to see the caller's context values")
+		}
+		return 1, nil
+	})
+	if err != nil || val != 1 {
+		t.Fatalf("expected 1, got %d, %v", val, err)
+	}
+	if val, ok := c.Peek("a"); !ok || val != 1 {
+		t.Fatal("expected the loaded value to be cached")
+	}
+}
+
+func TestGetOrComputeCtx_AlreadyCanceled(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := c.GetOrComputeCtx(ctx, "a", func(ctx context.Context) (int, error) {
+		t.Error("expected the loader not to run")
+		return 1, nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+	if n := c.Stats().LoadsInitiated; n != 0 {
+		t.Fatalf("expected no load to start, got %d", n)
+	}
+}
+
+func TestGetOrComputeCtx_CanceledWhileLoading(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	canceled := make(chan struct{})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := c.GetOrComputeCtx(ctx, "a", func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		close(canceled)
+		return 0, ctx.Err()
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("expected the abandoned load to be canceled")
+	}
+	if c.Has("a") {
+		t.Fatal("expected nothing to be cached")
+	}
+}
+
+func TestGetOrComputeCtx_WaiterCancelDoesNotAffectOthers(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	loader := func(ctx context.Context) (int, error) {
+		close(started)
+		select {
+		case <-release:
+			return 42, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+
+	initiator, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		_, err := c.GetOrComputeCtx(initiator, "a", loader)
+		errs <- err
+	}()
+	<-started
+
+	result := make(chan int, 1)
+	go func() {
+		val, _ := c.GetOrComputeCtx(context.Background(), "a", func(ctx context.Context) (int, error) {
+			t.Error("expected the waiter to share the running load")
+			return 0, nil
+		})
+		result <- val
+	}()
+
+	waitForWaiters(t, c, "a", 2)
+
+	waiter, cancelWaiter := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelWaiter()
+	if _, err := c.GetOrComputeCtx(waiter, "a", loader); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the waiter to return on its own deadline, got %v", err)
+	}
+
+	cancel()
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the initiator to return when canceled, got %v", err)
+	}
+
+	close(release)
+	if val := <-result; val != 42 {
+		t.Fatalf("expected the remaining waiter to get the shared result, got %d", val)
+	}
+}
+
+func TestRefresh_JoinedCallerCancelDoesNotCancel(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	release := make(chan struct{})
+	c.refresh("a", func(ctx context.Context) (int, error) {
+		select {
+		case <-release:
+			return 1, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	})
+
+	st := c.stripe("a")
+	st.mu.Lock()
+	cl := st.calls["a"]
+	st.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := c.GetOrComputeCtx(ctx, "a", func(context.Context) (int, error) {
+		return 2, nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	close(release)
+	<-cl.done
+
+	if val, ok := c.Get("a"); !ok || val != 1 {
+		t.Fatalf("expected the refresh to store its result, got %d, %v", val, ok)
+	}
+}
+
+func TestGetOrComputeCtx_FreshLoadAfterAbandon(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	c.GetOrComputeCtx(ctx, "a", func(ctx context.Context) (int, error) {
+		close(started)
+		time.Sleep(20 * time.Millisecond)
+		return 1, nil
+	})
+
+	val, err := c.GetOrComputeCtx(context.Background(), "a", func(ctx context.Context) (int, error) {
+		return 2, nil
+	})
+	if err != nil || val != 2 {
+		t.Fatalf("expected a fresh load instead of joining the abandoned one, got %d, %v", val, err)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	if val, _ := c.Peek("a"); val != 2 {
+		t.Fatalf("expected the abandoned load not to overwrite the fresh value, got %d", val)
+	}
+}
+
+func waitForWaiters(t *testing.T, c *Cache[string, int], key string, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		st := c.stripe(key)
+		st.mu.Lock()
+		cl, ok := st.calls[key]
+		waiting := ok && cl.waiters >= n
+		st.mu.Unlock()
+
+		if waiting {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("expected %d callers to wait for %q", n, key)
+}
@@ -0,0 +1,23 @@
+package mcache
+
+func (c *Cache[K, V]) costOf(key K, value V) int64 {
+	if c.coster == nil {
+		return 1
+	}
+
+	return c.coster(key, value)
+}
+
+func (c *Cache[K, V]) SetWithCost(key K, value V, cost int64) {
+	c.mu.Lock()
+	defer c.unlock()
+
+	c.setCost(key, value, c.ttl, cost)
+}
+
+func (c *Cache[K, V]) Cost() int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.cost
+}
@@ -0,0 +1,216 @@
+package mcache
+
+import "testing"
+
+func newCostTestCache(maxCost int64, opts ...Option[string, string]) *Cache[string, string] {
+	opts = append([]Option[string, string]{
+		WithMaxCost[string, string](maxCost),
+		WithCoster(func(key string, value string) int64 {
+			return int64(len(value))
+		}),
+	}, opts...)
+	return NewCache(opts...)
+}
+
+func TestCost_Tracks(t *testing.T) {
+	c := newCostTestCache(0)
+	defer c.Close()
+
+	c.Set("a", "xxx")
+	c.Set("b", "xx")
+	if n := c.Cost(); n != 5 {
+		t.Fatalf("expected cost 5, got %d", n)
+	}
+
+	c.Set("a", "x")
+	if n := c.Cost(); n != 3 {
+		t.Fatalf("expected cost 3 after overwrite, got %d", n)
+	}
+
+	c.Delete("b")
+	if n := c.Cost(); n != 1 {
+		t.Fatalf("expected cost 1 after delete, got %d", n)
+	}
+
+	c.Clear()
+	if n := c.Cost(); n != 0 {
+		t.Fatalf("expected cost 0 after clear, got %d", n)
+	}
+}
+
+func TestCost_DefaultsToOnePerEntry(t *testing.T) {
+	c := NewCache(WithMaxCost[string, int](2))
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("c", 3)
+
+	if n := c.Cost(); n != 2 {
+		t.Fatalf("expected cost 2, got %d", n)
+	}
+	if c.Has("a") {
+		t.Fatal("expected oldest entry to be evicted")
+	}
+}
+
+func TestMaxCost_EvictsUntilUnderLimit(t *testing.T) {
+	var evicted []string
+	c := newCostTestCache(10, WithOnEvict(func(key string, value string, reason EvictReason) {
+		if reason == Evicted {
+			evicted = append(evicted, key)
+		}
+	}))
+	defer c.Close()
+
+	c.Set("a", "xxxx")
+	c.Set("b", "xxx")
+	c.Set("c", "xx")
+	c.Get("a")
+
+	c.Set("d", "xxxxxx")
+
+	if len(evicted) != 2 || evicted[0] != "b" || evicted[1] != "c" {
+		t.Fatalf("expected b and c to be evicted in LRU order, got %v", evicted)
+	}
+	if n := c.Cost(); n != 10 {
+		t.Fatalf("expected cost 10, got %d", n)
+	}
+	if st := c.Stats(); st.Evictions != 2 {
+		t.Fatalf("expected 2 evictions, got %d", st.Evictions)
+	}
+}
+
+func TestMaxCost_OverwriteGrowsEntry(t *testing.T) {
+	c := newCostTestCache(6, WithEvictionPolicy[string, string](FIFO))
+	defer c.Close()
+
+	c.Set("a", "xx")
+	c.Set("b", "xx")
+	c.Set("c", "xx")
+
+	c.Set("a", "xxxx")
+
+	if v, ok := c.Peek("a"); !ok || v != "xxxx" {
+		t.Fatalf("expected the overwritten entry to be kept, got %q, %v", v, ok)
+	}
+	if c.Has("b") {
+		t.Fatal("expected another entry to be evicted to make room")
+	}
+	if n := c.Cost(); n != 6 {
+		t.Fatalf("expected cost 6, got %d", n)
+	}
+}
+
+func TestMaxCost_RejectsOversizedValue(t *testing.T) {
+	c := newCostTestCache(5)
+	defer c.Close()
+
+	c.Set("a", "xx")
+	c.Set("b", "xx")
+	c.Set("b", "xxxxxx")
+	c.Set("c", "xxxxxxx")
+
+	if c.Has("b") || c.Has("c") {
+		t.Fatal("expected values larger than the limit not to be stored")
+	}
+	if !c.Has("a") {
+		t.Fatal("expected other entries to be left alone")
+	}
+	if n := c.Cost(); n != 2 {
+		t.Fatalf("expected cost 2, got %d", n)
+	}
+}
+
+func TestMaxCost_WithMaxEntries(t *testing.T) {
+	c := newCostTestCache(100, WithMaxEntries[string, string](2))
+	defer c.Close()
+
+	c.Set("a", "x")
+	c.Set("b", "x")
+	c.Set("c", "x")
+
+	if n := c.Count(); n != 2 {
+		t.Fatalf("expected entry limit to apply alongside cost, got %d", n)
+	}
+}
+
+func TestMaxCost_Clone(t *testing.T) {
+	c := newCostTestCache(10)
+	defer c.Close()
+
+	c.Set("a", "xxxx")
+	c.Set("b", "xxx")
+
+	clone := c.Clone()
+	defer clone.Close()
+
+	if n := clone.Cost(); n != 7 {
+		t.Fatalf("expected clone cost 7, got %d", n)
+	}
+
+	clone.Set("c", "xxxxx")
+	if clone.Has("a") {
+		t.Fatal("expected the clone to enforce the cost limit")
+	}
+}
+
+func TestSetWithCost(t *testing.T) {
+	calls := 0
+	c := NewCache(
+		WithMaxCost[string, string](10),
+		WithCoster(func(key string, value string) int64 {
+			calls++
+			return int64(len(value))
+		}),
+	)
+	defer c.Close()
+
+	c.SetWithCost("a", "x", 6)
+	if calls != 0 {
+		t.Fatalf("expected coster not to run, ran %d times", calls)
+	}
+	if n := c.Cost(); n != 6 {
+		t.Fatalf("expected cost 6, got %d", n)
+	}
+
+	c.SetWithCost("b", "x", 5)
+	if c.Has("a") {
+		t.Fatal("expected explicit cost to drive eviction")
+	}
+	if n := c.Cost(); n != 5 {
+		t.Fatalf("expected cost 5, got %d", n)
+	}
+}
+
+func TestSetWithCost_OverwriteAndDelete(t *testing.T) {
+	c := newCostTestCache(0)
+	defer c.Close()
+
+	c.SetWithCost("a", "x", 7)
+	c.Set("a", "xx")
+	if n := c.Cost(); n != 2 {
+		t.Fatalf("expected overwrite to replace the explicit cost, got %d", n)
+	}
+
+	c.SetWithCost("a", "xx", 9)
+	if n := c.Cost(); n != 9 {
+		t.Fatalf("expected cost 9, got %d", n)
+	}
+
+	c.Delete("a")
+	if n := c.Cost(); n != 0 {
+		t.Fatalf("expected delete to subtract the explicit cost, got %d", n)
+	}
+}
+
+func TestSetWithCost_UsesDefaultTTL(t *testing.T) {
+	c := NewCache(WithTTL[string, int](ttl), WithClock[string, int](newFakeClock()))
+	defer c.Close()
+
+	c.SetWithCost("a", 1, 3)
+
+	if remaining, ok := c.GetTTL("a"); !ok || remaining != ttl {
+		t.Fatalf("expected default ttl, got %v, %v", remaining, ok)
+	}
+}
@@ -0,0 +1,119 @@
+package mcache
+
+type EvictReason int
+
+const (
+	Expired EvictReason = iota
+	Deleted
+	Replaced
+	Evicted
+	Cleared
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case Expired:
+		return "expired"
+	case Deleted:
+		return "deleted"
+	case Replaced:
+		return "replaced"
+	case Evicted:
+		return "evicted"
+	case Cleared:
+		return "cleared"
+	default:
+		return "unknown"
+	}
+}
+
+const eventBufferSize = 128
+
+type Event[K comparable, V any] struct {
+	Key    K
+	Value  V
+	Reason EvictReason
+}
+
+func (c *Cache[K, V]) Events() <-chan Event[K, V] {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.events == nil {
+		c.events = c.subscribe()
+	}
+
+	return c.events
+}
+
+func (c *Cache[K, V]) Subscribe() (<-chan Event[K, V], func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := c.subscribe()
+	return ch, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		if _, ok := c.subs[ch]; ok {
+			delete(c.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// subscribe registers a new event channel. Close closes every registered
+// channel, so one created afterwards is returned already closed.
+func (c *Cache[K, V]) subscribe() chan Event[K, V] {
+	ch := make(chan Event[K, V], c.eventBuffer)
+	if c.closed {
+		close(ch)
+		return ch
+	}
+
+	if c.subs == nil {
+		c.subs = make(map[chan Event[K, V]]struct{})
+	}
+	c.subs[ch] = struct{}{}
+
+	return ch
+}
+
+func (c *Cache[K, V]) DroppedEvents() uint64 {
+	return c.droppedEvents.Load()
+}
+
+func (c *Cache[K, V]) notify(key K, value V, reason EvictReason) {
+	if c.onEvict == nil && len(c.subs) == 0 {
+		return
+	}
+
+	c.pending = append(c.pending, Event[K, V]{Key: key, Value: value, Reason: reason})
+}
+
+// unlock releases the write lock and dispatches the eviction events collected
+// while it was held. Channel sends never block, so they happen before the
+// unlock to stay ordered with Close; callbacks run afterwards so they are free
+// to use the cache.
+func (c *Cache[K, V]) unlock() {
+	pending := c.pending
+	c.pending = nil
+
+	for ch := range c.subs {
+		for _, e := range pending {
+			select {
+			case ch <- e:
+			default:
+				c.droppedEvents.Add(1)
+			}
+		}
+	}
+
+	c.mu.Unlock()
+
+	if c.onEvict != nil {
+		for _, e := range pending {
+			c.onEvict(e.Key, e.Value, e.Reason)
+		}
+	}
+}
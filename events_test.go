@@ -0,0 +1,377 @@
+package mcache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+type evictRecord struct {
+	key    string
+	value  int
+	reason EvictReason
+}
+
+type evictRecorder struct {
+	mu      sync.Mutex
+	records []evictRecord
+}
+
+func (r *evictRecorder) record(key string, value int, reason EvictReason) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.records = append(r.records, evictRecord{key: key, value: value, reason: reason})
+}
+
+func (r *evictRecorder) all() []evictRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]evictRecord(nil), r.records...)
+}
+
+func TestOnEvict(t *testing.T) {
+	rec := &evictRecorder{}
+	c := NewCache(
+		WithTTL[string, int](ttl),
+		WithCleanupInterval[string, int](0),
+		WithClock[string, int](newFakeClock()),
+		WithOnEvict(rec.record),
+		WithMaxEntries[string, int](3),
+	)
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Set("a", 2)
+	c.Delete("a")
+
+	c.Set("b", 3)
+	advance(c, ttl)
+	c.Get("b")
+
+	c.SetWithTTL("c", 4, NoExpiration)
+	c.SetWithTTL("d", 5, NoExpiration)
+	c.SetWithTTL("e", 6, NoExpiration)
+	c.SetWithTTL("f", 7, NoExpiration)
+
+	c.Clear()
+
+	want := []evictRecord{
+		{"a", 1, Replaced},
+		{"a", 2, Deleted},
+		{"b", 3, Expired},
+		{"c", 4, Evicted},
+	}
+
+	got := rec.all()
+	if len(got) != len(want)+3 {
+		t.Fatalf("expected %d events, got %d: %v", len(want)+3, len(got), got)
+	}
+	for i, w := range want {
+		if got[i] != w {
+			t.Fatalf("event %d: expected %v, got %v", i, w, got[i])
+		}
+	}
+	for _, e := range got[len(want):] {
+		if e.reason != Cleared {
+			t.Fatalf("expected cleared event, got %v", e)
+		}
+	}
+}
+
+func TestOnEvict_Cleanup(t *testing.T) {
+	rec := &evictRecorder{}
+	c := newTestCache(WithOnEvict(rec.record))
+	defer c.Close()
+
+	c.Set("a", 1)
+	advance(c, ttl)
+	time.Sleep(cleanupInterval + 10*time.Millisecond)
+
+	got := rec.all()
+	if len(got) != 1 || got[0] != (evictRecord{"a", 1, Expired}) {
+		t.Fatalf("expected one expired event, got %v", got)
+	}
+}
+
+func TestEvictReason_String(t *testing.T) {
+	if s := Evicted.String(); s != "evicted" {
+		t.Fatalf("expected evicted, got %q", s)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	c := newTestCache()
+
+	events := c.Events()
+
+	c.Set("a", 1)
+	c.Set("a", 2)
+	c.Delete("a")
+
+	want := []Event[string, int]{
+		{Key: "a", Value: 1, Reason: Replaced},
+		{Key: "a", Value: 2, Reason: Deleted},
+	}
+
+	for i, w := range want {
+		select {
+		case e := <-events:
+			if e != w {
+				t.Fatalf("event %d: expected %v, got %v", i, w, e)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for event %d", i)
+		}
+	}
+
+	c.Close()
+
+	if _, ok := <-events; ok {
+		t.Fatal("expected events channel to be closed")
+	}
+}
+
+func TestEvents_SameChannel(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	if c.Events() != c.Events() {
+		t.Fatal("expected Events to return the same channel")
+	}
+}
+
+func TestEvents_DropsWhenFull(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Events()
+
+	for i := range eventBufferSize + 10 {
+		c.Set("a", i)
+	}
+
+	if n := c.DroppedEvents(); n != 9 {
+		t.Fatalf("expected 9 dropped events, got %d", n)
+	}
+}
+
+func TestWithEventBuffer(t *testing.T) {
+	c := newTestCache(WithEventBuffer[string, int](2))
+	defer c.Close()
+
+	events := c.Events()
+	if n := cap(events); n != 2 {
+		t.Fatalf("expected buffer of 2, got %d", n)
+	}
+
+	for i := range 5 {
+		c.Set("a", i)
+	}
+	if n := c.DroppedEvents(); n != 2 {
+		t.Fatalf("expected 2 dropped events, got %d", n)
+	}
+
+	<-events
+	c.Set("a", 5)
+	if n := c.DroppedEvents(); n != 2 {
+		t.Fatalf("expected delivery once the consumer catches up, got %d dropped", n)
+	}
+}
+
+func TestWithEventBuffer_NonPositive(t *testing.T) {
+	c := newTestCache(WithEventBuffer[string, int](0))
+	defer c.Close()
+
+	if n := cap(c.Events()); n != eventBufferSize {
+		t.Fatalf("expected default buffer of %d, got %d", eventBufferSize, n)
+	}
+}
+
+func TestEvents_AfterClose(t *testing.T) {
+	c := newTestCache()
+	c.Close()
+
+	if _, ok := <-c.Events(); ok {
+		t.Fatal("expected events channel to be closed")
+	}
+}
+
+func TestEvents_WriteAfterClose(t *testing.T) {
+	c := newTestCache()
+	events := c.Events()
+	c.Set("a", 1)
+	c.Close()
+
+	c.Delete("a")
+
+	if _, ok := <-events; ok {
+		t.Fatal("expected events channel to be closed")
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	a, cancelA := c.Subscribe()
+	defer cancelA()
+	b, cancelB := c.Subscribe()
+	defer cancelB()
+
+	c.Set("a", 1)
+	c.Delete("a")
+
+	want := Event[string, int]{Key: "a", Value: 1, Reason: Deleted}
+	for i, ch := range []<-chan Event[string, int]{a, b} {
+		select {
+		case e := <-ch:
+			if e != want {
+				t.Fatalf("subscriber %d: expected %v, got %v", i, want, e)
+			}
+		default:
+			t.Fatalf("subscriber %d: expected an event", i)
+		}
+	}
+}
+
+func TestSubscribe_Cancel(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	a, cancelA := c.Subscribe()
+	b, cancelB := c.Subscribe()
+	defer cancelB()
+
+	cancelA()
+	cancelA()
+
+	if _, ok := <-a; ok {
+		t.Fatal("expected canceled subscription to be closed")
+	}
+
+	c.Set("a", 1)
+	c.Delete("a")
+
+	if e := <-b; e.Key != "a" || e.Reason != Deleted {
+		t.Fatalf("expected other subscribers to keep receiving, got %v", e)
+	}
+	if n := c.DroppedEvents(); n != 0 {
+		t.Fatalf("expected no dropped events, got %d", n)
+	}
+}
+
+func TestSubscribe_Close(t *testing.T) {
+	c := newTestCache()
+
+	a, cancelA := c.Subscribe()
+	b, _ := c.Subscribe()
+	c.Close()
+	cancelA()
+
+	for i, ch := range []<-chan Event[string, int]{a, b} {
+		if _, ok := <-ch; ok {
+			t.Fatalf("subscriber %d: expected channel to be closed", i)
+		}
+	}
+
+	late, cancel := c.Subscribe()
+	defer cancel()
+	if _, ok := <-late; ok {
+		t.Fatal("expected subscription after Close to be closed")
+	}
+}
+
+func TestOnEvict_OverwriteExpired(t *testing.T) {
+	rec := &evictRecorder{}
+	c := newTestCache(WithCleanupInterval[string, int](0), WithOnEvict(rec.record))
+	defer c.Close()
+
+	c.Set("a", 1)
+	advance(c, ttl)
+	c.Set("a", 2)
+
+	got := rec.all()
+	if len(got) != 1 || got[0] != (evictRecord{"a", 1, Expired}) {
+		t.Fatalf("expected overwrite of an expired entry to report Expired, got %v", got)
+	}
+}
+
+func TestOnEvict_ReentrantCallback(t *testing.T) {
+	var (
+		c       *Cache[string, int]
+		mu      sync.Mutex
+		reasons = map[EvictReason]int{}
+	)
+
+	c = newTestCache(
+		WithMaxEntries[string, int](3),
+		WithSlidingTTL[string, int](),
+		WithOnEvict(func(key string, value int, reason EvictReason) {
+			if key == "audit" {
+				return
+			}
+
+			// Every kind of cache call, including ones that take the
+			// write lock, must be safe from inside the callback.
+			c.Get(key)
+			c.Count()
+			c.Set("audit", value)
+			c.Delete("audit")
+
+			mu.Lock()
+			reasons[reason]++
+			mu.Unlock()
+		}),
+	)
+	defer c.Close()
+
+	ops := []struct {
+		name string
+		fn   func()
+	}{
+		{"Replaced", func() { c.Set("a", 1); c.Set("a", 2) }},
+		{"Deleted", func() { c.Delete("a") }},
+		{"Evicted", func() {
+			for i := range 5 {
+				c.Set(strconv.Itoa(i), i)
+			}
+		}},
+		{"GetAndDelete", func() { c.GetAndDelete("4") }},
+		{"Expired", func() { advance(c, ttl); c.DeleteExpired() }},
+		{"Sweep", func() {
+			c.Set("b", 1)
+			advance(c, ttl)
+			time.Sleep(cleanupInterval + 10*time.Millisecond)
+		}},
+		{"Cleared", func() { c.Set("c", 1); c.Clear() }},
+		{"Resize", func() {
+			c.SetMany(map[string]int{"x": 1, "y": 2, "z": 3})
+			c.Resize(1)
+		}},
+	}
+
+	for _, op := range ops {
+		done := make(chan struct{})
+		go func() {
+			op.fn()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("%s: callback re-entering the cache deadlocked", op.name)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, r := range []EvictReason{Replaced, Deleted, Evicted, Expired, Cleared} {
+		if reasons[r] == 0 {
+			t.Fatalf("expected a %v event, got %v", r, reasons)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package mcache
+
+import "container/list"
+
+type EvictionPolicy int
+
+const (
+	LRU EvictionPolicy = iota
+	LFU
+	FIFO
+	Random
+)
+
+func (c *Cache[K, V]) touchOrder(it *item[K, V]) {
+	if it.elem != nil && c.policy == LRU {
+		c.order.MoveToFront(it.elem)
+	}
+}
+
+func (c *Cache[K, V]) bounded() bool {
+	return c.maxEntries > 0 || c.maxCost > 0
+}
+
+// Bounded caches track entries for eviction: Random keeps them in slots, so
+// a victim can be drawn in constant time, and the other policies keep them
+// in order.
+func (c *Cache[K, V]) ordered() bool {
+	return c.bounded() && c.policy != Random
+}
+
+func (c *Cache[K, V]) track(it *item[K, V]) {
+	switch {
+	case c.ordered():
+		it.elem = c.order.PushFront(it.key)
+	case c.bounded():
+		it.slot = len(c.slots)
+		c.slots = append(c.slots, it)
+	}
+}
+
+func (c *Cache[K, V]) untrack(it *item[K, V]) {
+	switch {
+	case it.elem != nil:
+		c.order.Remove(it.elem)
+		it.elem = nil
+	case c.bounded() && c.policy == Random:
+		last := c.slots[len(c.slots)-1]
+		c.slots[it.slot], last.slot = last, it.slot
+		c.slots[len(c.slots)-1] = nil
+		c.slots = c.slots[:len(c.slots)-1]
+	}
+}
+
+// victim picks the entry to evict, never choosing skip, which is the entry
+// being overwritten when a write needs room.
+func (c *Cache[K, V]) victim(skip *item[K, V]) (K, bool) {
+	if c.policy == Random {
+		return c.randomVictim(skip)
+	}
+
+	var skipElem *list.Element
+	if skip != nil {
+		skipElem = skip.elem
+	}
+
+	elem := c.order.Back()
+	if elem != nil && elem == skipElem {
+		elem = elem.Prev()
+	}
+	if elem == nil {
+		var zero K
+		return zero, false
+	}
+
+	if c.policy == LFU {
+		// The list is in insertion order, so scanning from the back breaks
+		// ties in favor of evicting the oldest entry.
+		least := elem
+		leastHits := c.items[elem.Value.(K)].hits.Load()
+
+		for e := elem.Prev(); e != nil; e = e.Prev() {
+			if e == skipElem {
+				continue
+			}
+			if hits := c.items[e.Value.(K)].hits.Load(); hits < leastHits {
+				least, leastHits = e, hits
+			}
+		}
+
+		elem = least
+	}
+
+	return elem.Value.(K), true
+}
+
+func (c *Cache[K, V]) randomVictim(skip *item[K, V]) (K, bool) {
+	n := len(c.slots)
+	if skip != nil {
+		n--
+	}
+	if n <= 0 {
+		var zero K
+		return zero, false
+	}
+
+	// Drawing from all slots but the last and mapping skip to the last one
+	// keeps the choice uniform over the other entries.
+	i := c.rnd.IntN(n)
+	if skip != nil && i == skip.slot {
+		i = len(c.slots) - 1
+	}
+
+	return c.slots[i].key, true
+}
+
+func (c *Cache[K, V]) evict(skip *item[K, V]) bool {
+	key, ok := c.victim(skip)
+	if !ok {
+		return false
+	}
+
+	if c.items[key].expired(c.clock.Now()) {
+		c.expire(key)
+	} else {
+		c.remove(key, Evicted)
+		c.evicted(key)
+	}
+
+	return true
+}
+
+// evicted records that key was removed to respect a capacity limit.
+func (c *Cache[K, V]) evicted(key K) {
+	c.stats.evictions.Add(1)
+	if c.evictedKeys != nil {
+		*c.evictedKeys = append(*c.evictedKeys, key)
+	}
+}
+
+// makeRoom evicts until cost more can be added without exceeding the limits.
+// For an overwrite, it is the existing entry and cost is the change in cost;
+// for a new entry, it is nil.
+func (c *Cache[K, V]) makeRoom(it *item[K, V], cost int64) {
+	for c.full(it == nil, cost) && c.evict(it) {
+	}
+}
+
+func (c *Cache[K, V]) full(adding bool, cost int64) bool {
+	if adding && c.maxEntries > 0 && len(c.items) >= c.maxEntries {
+		return true
+	}
+
+	return c.maxCost > 0 && c.cost+cost > c.maxCost
+}
+
+func (c *Cache[K, V]) Resize(maxEntries int) int {
+	c.mu.Lock()
+	defer c.unlock()
+
+	wasBounded := c.bounded()
+	c.maxEntries = max(maxEntries, 0)
+
+	switch {
+	case !c.bounded():
+		for _, it := range c.items {
+			it.elem = nil
+		}
+		c.order.Init()
+		c.slots = nil
+		return 0
+	case !wasBounded:
+		// Unbounded caches do not track order, so existing entries start
+		// out in arbitrary order.
+		for _, it := range c.items {
+			c.track(it)
+		}
+	}
+
+	evicted := 0
+	for c.maxEntries > 0 && len(c.items) > c.maxEntries && c.evict(nil) {
+		evicted++
+	}
+
+	return evicted
+}
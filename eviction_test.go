@@ -0,0 +1,473 @@
+package mcache
+
+import (
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func newBoundedTestCache(maxEntries int, opts ...Option[string, int]) *Cache[string, int] {
+	opts = append(opts, WithMaxEntries[string, int](maxEntries))
+	return NewCache(opts...)
+}
+
+func TestMaxEntries_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := newBoundedTestCache(3)
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("c", 3)
+
+	// "a" becomes the most recently used, leaving "b" as the oldest.
+	c.Get("a")
+	c.Set("d", 4)
+
+	if _, ok := c.Get("b"); ok {
+		t.Fatal("expected least recently used key to be evicted")
+	}
+	for _, k := range []string{"a", "c", "d"} {
+		if _, ok := c.Get(k); !ok {
+			t.Fatalf("expected key %q to remain", k)
+		}
+	}
+	if n := c.Count(); n != 3 {
+		t.Fatalf("expected count 3, got %d", n)
+	}
+	if n := c.Stats().Evictions; n != 1 {
+		t.Fatalf("expected 1 eviction, got %d", n)
+	}
+}
+
+func TestMaxEntries_OverwriteDoesNotEvict(t *testing.T) {
+	c := newBoundedTestCache(2)
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("a", 3)
+
+	if n := c.Count(); n != 2 {
+		t.Fatalf("expected count 2, got %d", n)
+	}
+	if n := c.Stats().Evictions; n != 0 {
+		t.Fatalf("expected no evictions, got %d", n)
+	}
+
+	// Overwriting "a" made it the most recently used.
+	c.Set("c", 4)
+
+	if _, ok := c.Get("b"); ok {
+		t.Fatal("expected key b to be evicted")
+	}
+}
+
+func TestMaxEntries_FillPastCapacity(t *testing.T) {
+	c := newBoundedTestCache(10)
+	defer c.Close()
+
+	for i := range 100 {
+		c.Set(strconv.Itoa(i), i)
+	}
+
+	if n := c.Count(); n != 10 {
+		t.Fatalf("expected count 10, got %d", n)
+	}
+	if n := c.order.Len(); n != 10 {
+		t.Fatalf("expected order list of 10, got %d", n)
+	}
+	for i := 90; i < 100; i++ {
+		if _, ok := c.Get(strconv.Itoa(i)); !ok {
+			t.Fatalf("expected key %d to remain", i)
+		}
+	}
+}
+
+func TestMaxEntries_DeleteKeepsOrderConsistent(t *testing.T) {
+	c := newBoundedTestCache(2)
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Delete("a")
+	c.Release("b")
+	c.Set("c", 3)
+
+	if n := c.order.Len(); n != 1 {
+		t.Fatalf("expected order list of 1, got %d", n)
+	}
+
+	c.Clear()
+
+	if n := c.order.Len(); n != 0 {
+		t.Fatalf("expected empty order list, got %d", n)
+	}
+}
+
+func TestEvictionPolicy_LFU(t *testing.T) {
+	c := newBoundedTestCache(3, WithEvictionPolicy[string, int](LFU))
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("c", 3)
+
+	c.Get("a")
+	c.Get("a")
+	c.Get("b")
+	c.Get("c")
+	c.Get("c")
+
+	c.Set("d", 4)
+
+	if _, ok := c.Peek("b"); ok {
+		t.Fatal("expected least frequently used key to be evicted")
+	}
+	for _, k := range []string{"a", "c", "d"} {
+		if _, ok := c.Peek(k); !ok {
+			t.Fatalf("expected key %q to remain", k)
+		}
+	}
+}
+
+func TestEvictionPolicy_LFUTieBreaksByOldest(t *testing.T) {
+	c := newBoundedTestCache(3, WithEvictionPolicy[string, int](LFU))
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("c", 3)
+	c.Set("d", 4)
+
+	if _, ok := c.Peek("a"); ok {
+		t.Fatal("expected oldest key to be evicted on a tie")
+	}
+	if n := c.Count(); n != 3 {
+		t.Fatalf("expected count 3, got %d", n)
+	}
+}
+
+func TestEvictionPolicy_FIFO(t *testing.T) {
+	c := newBoundedTestCache(3, WithEvictionPolicy[string, int](FIFO))
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("c", 3)
+
+	// Access and overwrites do not change insertion order.
+	c.Get("a")
+	c.Set("a", 10)
+	c.Set("d", 4)
+
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected earliest inserted key to be evicted")
+	}
+
+	c.Set("e", 5)
+
+	if _, ok := c.Get("b"); ok {
+		t.Fatal("expected next earliest inserted key to be evicted")
+	}
+	for _, k := range []string{"c", "d", "e"} {
+		if _, ok := c.Get(k); !ok {
+			t.Fatalf("expected key %q to remain", k)
+		}
+	}
+}
+
+func newRandomTestCache(maxEntries int, seed uint64, opts ...Option[string, int]) *Cache[string, int] {
+	opts = append([]Option[string, int]{
+		WithEvictionPolicy[string, int](Random),
+		WithRand[string, int](rand.New(rand.NewPCG(seed, seed))),
+	}, opts...)
+	return newBoundedTestCache(maxEntries, opts...)
+}
+
+func checkSlots(t *testing.T, c *Cache[string, int]) {
+	t.Helper()
+
+	if len(c.slots) != len(c.items) {
+		t.Fatalf("expected %d slots, got %d", len(c.items), len(c.slots))
+	}
+	for i, it := range c.slots {
+		if it.slot != i || c.items[it.key] != it {
+			t.Fatalf("slot %d holds %q with slot %d", i, it.key, it.slot)
+		}
+	}
+	if n := c.order.Len(); n != 0 {
+		t.Fatalf("expected no order list, got %d entries", n)
+	}
+}
+
+func TestEvictionPolicy_Random(t *testing.T) {
+	rec := &evictRecorder{}
+	c := newRandomTestCache(10, 1, WithOnEvict(rec.record))
+	defer c.Close()
+
+	for i := range 100 {
+		c.Set(strconv.Itoa(i), i)
+		checkSlots(t, c)
+	}
+
+	if n := c.Count(); n != 10 {
+		t.Fatalf("expected count 10, got %d", n)
+	}
+	records := rec.all()
+	if len(records) != 90 {
+		t.Fatalf("expected 90 evictions, got %d", len(records))
+	}
+	for _, r := range records {
+		if r.reason != Evicted {
+			t.Fatalf("expected reason Evicted, got %v", r.reason)
+		}
+	}
+	if n := c.Stats().Evictions; n != 90 {
+		t.Fatalf("expected 90 evictions in stats, got %d", n)
+	}
+
+	// FIFO would keep exactly the last ten keys.
+	old := 0
+	for _, k := range c.Keys() {
+		if n, _ := strconv.Atoi(k); n < 90 {
+			old++
+		}
+	}
+	if old == 0 {
+		t.Fatal("expected random eviction to keep some older entries")
+	}
+}
+
+func TestEvictionPolicy_RandomDeterministic(t *testing.T) {
+	run := func() []string {
+		rec := &evictRecorder{}
+		c := newRandomTestCache(5, 42, WithOnEvict(rec.record))
+		defer c.Close()
+
+		for i := range 50 {
+			c.Set(strconv.Itoa(i), i)
+		}
+
+		var keys []string
+		for _, r := range rec.all() {
+			keys = append(keys, r.key)
+		}
+		return keys
+	}
+
+	a, b := run(), run()
+	if !slices.Equal(a, b) {
+		t.Fatalf("expected the same victims for the same seed, got %v and %v", a, b)
+	}
+}
+
+func TestEvictionPolicy_RandomKeepsOverwrittenEntry(t *testing.T) {
+	c := newCostTestCache(3, WithEvictionPolicy[string, string](Random))
+	defer c.Close()
+
+	for i := range 50 {
+		c.Set("a", "x")
+		c.Set("b", "x")
+		c.Set("a", "xxx")
+
+		if !c.Has("a") || c.Has("b") {
+			t.Fatalf("iteration %d: expected the growing entry to evict the other one", i)
+		}
+	}
+}
+
+func TestEvictionPolicy_RandomDeleteAndResize(t *testing.T) {
+	c := newRandomTestCache(5, 1)
+	defer c.Close()
+
+	for i := range 5 {
+		c.Set(strconv.Itoa(i), i)
+	}
+
+	c.Delete("2")
+	c.GetAndDelete("4")
+	checkSlots(t, c)
+
+	if n := c.Resize(2); n != 1 {
+		t.Fatalf("expected 1 eviction, got %d", n)
+	}
+	checkSlots(t, c)
+
+	c.Resize(0)
+	if c.slots != nil {
+		t.Fatal("expected slots to be dropped when unbounded")
+	}
+	c.Set("x", 1)
+
+	c.Resize(2)
+	checkSlots(t, c)
+
+	clone := c.Clone()
+	defer clone.Close()
+	checkSlots(t, clone)
+
+	c.Clear()
+	checkSlots(t, c)
+}
+
+func TestResize_Shrink(t *testing.T) {
+	rec := &evictRecorder{}
+	c := newBoundedTestCache(4, WithOnEvict(rec.record))
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("c", 3)
+	c.Set("d", 4)
+	c.Get("a")
+
+	if n := c.Resize(2); n != 2 {
+		t.Fatalf("expected 2 evictions, got %d", n)
+	}
+
+	for _, k := range []string{"b", "c"} {
+		if _, ok := c.Peek(k); ok {
+			t.Fatalf("expected least recently used key %q to be evicted", k)
+		}
+	}
+	for _, k := range []string{"a", "d"} {
+		if _, ok := c.Peek(k); !ok {
+			t.Fatalf("expected key %q to remain", k)
+		}
+	}
+
+	records := rec.all()
+	if len(records) != 2 || records[0].reason != Evicted || records[1].reason != Evicted {
+		t.Fatalf("expected two Evicted callbacks, got %+v", records)
+	}
+	if st := c.Stats(); st.Evictions != 2 {
+		t.Fatalf("expected 2 evictions in stats, got %d", st.Evictions)
+	}
+
+	c.Set("e", 5)
+	if n := c.Count(); n != 2 {
+		t.Fatalf("expected new bound to be enforced, got count %d", n)
+	}
+}
+
+func TestResize_Grow(t *testing.T) {
+	c := newBoundedTestCache(2)
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+
+	if n := c.Resize(3); n != 0 {
+		t.Fatalf("expected no evictions, got %d", n)
+	}
+
+	c.Set("c", 3)
+	if n := c.Count(); n != 3 {
+		t.Fatalf("expected count 3, got %d", n)
+	}
+}
+
+func TestResize_FromUnbounded(t *testing.T) {
+	c := NewCache[string, int]()
+	defer c.Close()
+
+	for i := range 5 {
+		c.Set(strconv.Itoa(i), i)
+	}
+
+	if n := c.Resize(3); n != 2 {
+		t.Fatalf("expected 2 evictions, got %d", n)
+	}
+	if n := c.Count(); n != 3 {
+		t.Fatalf("expected count 3, got %d", n)
+	}
+
+	c.Set("new", 10)
+	if n := c.Count(); n != 3 {
+		t.Fatalf("expected bound to be enforced, got count %d", n)
+	}
+	if n := c.order.Len(); n != 3 {
+		t.Fatalf("expected order list to track all entries, got %d", n)
+	}
+}
+
+func TestResize_ToUnbounded(t *testing.T) {
+	c := newBoundedTestCache(2)
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+
+	if n := c.Resize(0); n != 0 {
+		t.Fatalf("expected no evictions, got %d", n)
+	}
+
+	c.Set("c", 3)
+	c.Delete("a")
+	if n := c.Count(); n != 2 {
+		t.Fatalf("expected count 2, got %d", n)
+	}
+	if n := c.order.Len(); n != 0 {
+		t.Fatalf("expected order list to be dropped, got %d", n)
+	}
+}
+
+func TestResize_ConcurrentWithGet(t *testing.T) {
+	c := newBoundedTestCache(10)
+	defer c.Close()
+
+	for i := range 10 {
+		c.Set(strconv.Itoa(i), i)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 100 {
+			c.Resize(i % 12)
+		}
+	}()
+
+	for i := range 1000 {
+		c.Get(strconv.Itoa(i % 10))
+	}
+	<-done
+}
+
+func TestEvictionPolicy_RandomPop(t *testing.T) {
+	pops := func(seed uint64) []string {
+		c := newRandomTestCache(100, seed, WithTTL[string, int](ttl), WithClock[string, int](newFakeClock()))
+		defer c.Close()
+
+		for i := range 20 {
+			c.Set(strconv.Itoa(i), i)
+		}
+		advance(c, ttl)
+		for i := 20; i < 40; i++ {
+			c.Set(strconv.Itoa(i), i)
+		}
+
+		var keys []string
+		for {
+			key, val, ok := c.Pop()
+			if !ok {
+				break
+			}
+			if strconv.Itoa(val) != key || val < 20 {
+				t.Fatalf("expected a live entry, got %q, %d", key, val)
+			}
+			checkSlots(t, c)
+			keys = append(keys, key)
+		}
+		return keys
+	}
+
+	a, b := pops(7), pops(7)
+	if len(a) != 20 {
+		t.Fatalf("expected 20 live entries, got %d", len(a))
+	}
+	if !slices.Equal(a, b) {
+		t.Fatalf("expected the same seed to pop in the same order, got %v and %v", a, b)
+	}
+}
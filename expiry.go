@@ -0,0 +1,54 @@
+package mcache
+
+import (
+	"container/heap"
+	"time"
+)
+
+// expiryHeap orders entries with an expiry time by soonest expiry, so
+// sweeps only visit entries that are due. Each item tracks its position in
+// the heap via index, which is -1 for entries that never expire.
+type expiryHeap[K comparable, V any] []*item[K, V]
+
+func (h expiryHeap[K, V]) Len() int { return len(h) }
+
+func (h expiryHeap[K, V]) Less(i, j int) bool {
+	return h[i].expiryTime.Before(h[j].expiryTime)
+}
+
+func (h expiryHeap[K, V]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *expiryHeap[K, V]) Push(x any) {
+	it := x.(*item[K, V])
+	it.index = len(*h)
+	*h = append(*h, it)
+}
+
+func (h *expiryHeap[K, V]) Pop() any {
+	old := *h
+	n := len(old)
+	it := old[n-1]
+	old[n-1] = nil
+	it.index = -1
+	*h = old[:n-1]
+	return it
+}
+
+func (c *Cache[K, V]) setExpiryTime(it *item[K, V], expiryTime time.Time) {
+	it.expiryTime = expiryTime
+
+	switch {
+	case expiryTime.IsZero():
+		if it.index >= 0 {
+			heap.Remove(&c.expiries, it.index)
+		}
+	case it.index >= 0:
+		heap.Fix(&c.expiries, it.index)
+	default:
+		heap.Push(&c.expiries, it)
+	}
+}
@@ -0,0 +1,404 @@
+package mcache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func checkExpiryHeap[K comparable, V any](t *testing.T, c *Cache[K, V]) {
+	t.Helper()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	withExpiry := 0
+	for k, it := range c.items {
+		if it.key != k {
+			t.Fatalf("item for %v records key %v", k, it.key)
+		}
+		if it.expiryTime.IsZero() {
+			if it.index != -1 {
+				t.Fatalf("never-expiring entry %v has heap index %d", k, it.index)
+			}
+			continue
+		}
+
+		withExpiry++
+		if it.index < 0 || it.index >= len(c.expiries) || c.expiries[it.index] != it {
+			t.Fatalf("entry %v has inconsistent heap index %d", k, it.index)
+		}
+	}
+
+	if len(c.expiries) != withExpiry {
+		t.Fatalf("expected %d heap entries, got %d", withExpiry, len(c.expiries))
+	}
+	for i := 1; i < len(c.expiries); i++ {
+		if c.expiries[i].expiryTime.Before(c.expiries[(i-1)/2].expiryTime) {
+			t.Fatalf("heap property violated at %d", i)
+		}
+	}
+}
+
+func TestExpiryHeap_TracksWrites(t *testing.T) {
+	c := newTestCache(WithCleanupInterval[string, int](0))
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.SetWithTTL("b", 2, 3*ttl)
+	c.SetWithTTL("c", 3, NoExpiration)
+	c.SetWithTTL("d", 4, ttl/2)
+	checkExpiryHeap(t, c)
+
+	c.SetWithTTL("a", 10, NoExpiration)
+	c.SetWithTTL("c", 30, ttl)
+	checkExpiryHeap(t, c)
+
+	c.Expire("b", ttl/4)
+	c.Persist("d")
+	c.Touch("c")
+	checkExpiryHeap(t, c)
+
+	c.Delete("b")
+	c.Release("c")
+	checkExpiryHeap(t, c)
+
+	c.Set("e", 5)
+	c.Clear()
+	checkExpiryHeap(t, c)
+
+	c.Set("f", 6)
+	checkExpiryHeap(t, c)
+}
+
+func TestExpiryHeap_SlidingTTL(t *testing.T) {
+	c := newTestCache(WithSlidingTTL[string, int](), WithCleanupInterval[string, int](0))
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	advance(c, ttl/2)
+	c.Get("a")
+	checkExpiryHeap(t, c)
+
+	advance(c, ttl/2)
+	if n := c.DeleteExpired(); n != 1 {
+		t.Fatalf("expected only the unread entry to expire, got %d", n)
+	}
+	if !c.Has("a") {
+		t.Fatal("expected slid entry to remain")
+	}
+	checkExpiryHeap(t, c)
+}
+
+func TestExpiryHeap_Eviction(t *testing.T) {
+	c := newBoundedTestCache(2, WithTTL[string, int](ttl), WithClock[string, int](newFakeClock()))
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("c", 3)
+	checkExpiryHeap(t, c)
+}
+
+func TestDeleteExpired_OnlyDueEntries(t *testing.T) {
+	c := newTestCache(WithCleanupInterval[string, int](0))
+	defer c.Close()
+
+	for i, d := range []time.Duration{ttl / 4, ttl / 2, ttl, 2 * ttl} {
+		c.SetWithTTL(string(rune('a'+i)), i, d)
+	}
+	c.SetWithTTL("never", 0, NoExpiration)
+
+	advance(c, ttl/2)
+	if n := c.DeleteExpired(); n != 2 {
+		t.Fatalf("expected 2 expired entries, got %d", n)
+	}
+	checkExpiryHeap(t, c)
+
+	advance(c, 3*ttl/2)
+	if n := c.DeleteExpired(); n != 2 {
+		t.Fatalf("expected 2 expired entries, got %d", n)
+	}
+	if !c.Has("never") {
+		t.Fatal("expected never-expiring entry to remain")
+	}
+	checkExpiryHeap(t, c)
+}
+
+func TestCleanupBatch_ReleasesLockBetweenBatches(t *testing.T) {
+	var (
+		c         *Cache[string, int]
+		remaining []int
+	)
+	c = newTestCache(
+		WithCleanupInterval[string, int](0),
+		WithCleanupBatch[string, int](10),
+		WithOnEvict(func(key string, value int, reason EvictReason) {
+			c.mu.RLock()
+			remaining = append(remaining, len(c.items))
+			c.mu.RUnlock()
+		}),
+	)
+	defer c.Close()
+
+	for i := range 35 {
+		c.Set(strconv.Itoa(i), i)
+	}
+	advance(c, ttl)
+
+	if n := c.DeleteExpired(); n != 35 {
+		t.Fatalf("expected 35 expired entries, got %d", n)
+	}
+	if len(remaining) != 35 {
+		t.Fatalf("expected 35 callbacks, got %d", len(remaining))
+	}
+	// Callbacks run after each batch releases the lock, so the first ones
+	// observe the entries that later batches have yet to remove.
+	for i, batchEnd := range []int{25, 15, 5, 0} {
+		if got := remaining[i*10]; got != batchEnd {
+			t.Fatalf("batch %d: expected %d entries left, got %d", i, batchEnd, got)
+		}
+	}
+}
+
+func TestCleanupBatch_DoesNotBlockGet(t *testing.T) {
+	if testing.Short() {
+		t.Skip("timing-sensitive")
+	}
+
+	c := NewCache(
+		WithTTL[int, int](ttl),
+		WithCleanupInterval[int, int](0),
+		WithCleanupBatch[int, int](100),
+		WithClock[int, int](newFakeClock()),
+	)
+	defer c.Close()
+
+	for i := range 200_000 {
+		c.Set(i, i)
+	}
+	c.SetWithTTL(-1, -1, NoExpiration)
+	advance(c, ttl)
+
+	done := make(chan time.Duration)
+	go func() {
+		start := time.Now()
+		c.DeleteExpired()
+		done <- time.Since(start)
+	}()
+
+	var longest time.Duration
+	for {
+		select {
+		case sweep := <-done:
+			if longest > sweep/4 {
+				t.Fatalf("Get blocked for %v during a %v sweep", longest, sweep)
+			}
+			return
+		default:
+		}
+
+		start := time.Now()
+		c.Get(-1)
+		longest = max(longest, time.Since(start))
+	}
+}
+
+func TestGracePeriod_KeepsStaleEntries(t *testing.T) {
+	rec := &evictRecorder{}
+	c := newTestCache(
+		WithGracePeriod[string, int](ttl/2),
+		WithCleanupInterval[string, int](0),
+		WithOnEvict(rec.record),
+	)
+	defer c.Close()
+
+	c.Set("a", 1)
+	advance(c, ttl)
+
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected Get to miss once the ttl has passed")
+	}
+	if c.Has("a") || c.Touch("a") {
+		t.Fatal("expected the stale entry to be treated as absent")
+	}
+	if val, stale, ok := c.stale("a"); !ok || !stale || val != 1 {
+		t.Fatalf("expected a stale 1, got %d, %v, %v", val, stale, ok)
+	}
+	if n := c.DeleteExpired(); n != 0 {
+		t.Fatalf("expected the sweep to keep entries within grace, removed %d", n)
+	}
+
+	advance(c, ttl/2)
+
+	if _, _, ok := c.stale("a"); ok {
+		t.Fatal("expected the entry to be gone after the grace period")
+	}
+	if n := c.DeleteExpired(); n != 1 {
+		t.Fatalf("expected the sweep to remove the entry, removed %d", n)
+	}
+	if records := rec.all(); len(records) != 1 || records[0].reason != Expired {
+		t.Fatalf("expected a single Expired event, got %v", records)
+	}
+}
+
+func TestGracePeriod_OverwriteStale(t *testing.T) {
+	rec := &evictRecorder{}
+	c := newTestCache(
+		WithGracePeriod[string, int](ttl),
+		WithMaxEntries[string, int](2),
+		WithCleanupInterval[string, int](0),
+		WithOnEvict(rec.record),
+	)
+	defer c.Close()
+
+	c.Set("a", 1)
+	advance(c, ttl)
+	c.Set("a", 2)
+
+	if val, ok := c.Get("a"); !ok || val != 2 {
+		t.Fatalf("expected 2, got %d, %v", val, ok)
+	}
+	if n := c.Len(); n != 1 {
+		t.Fatalf("expected the stale entry to be replaced, got %d entries", n)
+	}
+	if n := c.order.Len(); n != 1 {
+		t.Fatalf("expected one tracked entry, got %d", n)
+	}
+	checkExpiryHeap(t, c)
+
+	if records := rec.all(); len(records) != 1 || records[0].reason != Expired || records[0].value != 1 {
+		t.Fatalf("expected the stale value to be reported as expired, got %v", records)
+	}
+}
+
+func TestGetStale(t *testing.T) {
+	c := newTestCache(WithGracePeriod[string, int](ttl/2), WithCleanupInterval[string, int](0))
+	defer c.Close()
+
+	if _, stale, ok := c.GetStale("a"); ok || stale {
+		t.Fatalf("expected a miss for a missing key, got %v, %v", stale, ok)
+	}
+
+	c.Set("a", 1)
+	if val, stale, ok := c.GetStale("a"); !ok || stale || val != 1 {
+		t.Fatalf("expected fresh 1, got %d, %v, %v", val, stale, ok)
+	}
+
+	advance(c, ttl)
+	if val, stale, ok := c.GetStale("a"); !ok || !stale || val != 1 {
+		t.Fatalf("expected stale 1 within the grace period, got %d, %v, %v", val, stale, ok)
+	}
+
+	advance(c, ttl/2)
+	if _, stale, ok := c.GetStale("a"); ok || stale {
+		t.Fatalf("expected a miss after the grace period, got %v, %v", stale, ok)
+	}
+
+	if st := c.Stats(); st.Hits != 1 || st.Misses != 3 {
+		t.Fatalf("expected only the fresh read to count as a hit, got %+v", st)
+	}
+}
+
+func TestGetStale_WithoutGracePeriod(t *testing.T) {
+	c := newTestCache(WithCleanupInterval[string, int](0))
+	defer c.Close()
+
+	c.Set("a", 1)
+	advance(c, ttl)
+
+	if _, stale, ok := c.GetStale("a"); ok || stale {
+		t.Fatalf("expected expired entries to be absent without a grace period, got %v, %v", stale, ok)
+	}
+}
+
+func BenchmarkDeleteExpired_1M(b *testing.B) {
+	clock := newFakeClock()
+	c := NewCache(
+		WithTTL[int, int](10000*time.Hour),
+		WithCleanupInterval[int, int](0),
+		WithClock[int, int](clock),
+	)
+	defer c.Close()
+
+	for i := range 1_000_000 {
+		c.Set(i, i)
+	}
+
+	b.ResetTimer()
+	for i := range b.N {
+		b.StopTimer()
+		for j := range 100 {
+			c.SetWithTTL(-(i*100 + j + 1), j, time.Second)
+		}
+		clock.Advance(time.Second)
+		b.StartTimer()
+
+		if n := c.DeleteExpired(); n != 100 {
+			b.Fatalf("expected 100 expired entries, got %d", n)
+		}
+	}
+}
+
+func TestCleanup_ConcurrentGetSetStress(t *testing.T) {
+	if testing.Short() {
+		t.Skip("timing-sensitive")
+	}
+
+	const (
+		workers = 8
+		keys    = 32
+	)
+
+	for name, opt := range map[string]Option[string, int]{
+		"default": func(*options[string, int]) {},
+		"sliding": WithSlidingTTL[string, int](),
+		"lru":     WithMaxEntries[string, int](workers * keys * 2),
+		"batched": WithCleanupBatch[string, int](4),
+		"grace":   WithGracePeriod[string, int](time.Millisecond),
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := NewCache(
+				WithTTL[string, int](time.Millisecond),
+				WithCleanupInterval[string, int](time.Microsecond*100),
+				opt,
+			)
+			defer c.Close()
+
+			deadline := time.Now().Add(100 * time.Millisecond)
+			var wg sync.WaitGroup
+			for w := range workers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					// Each worker owns its keys, so a read returning anything
+					// but the last value written is a torn or misordered read.
+					last := make([]int, keys)
+					for seq := 1; time.Now().Before(deadline); seq++ {
+						k := seq % keys
+						key := strconv.Itoa(w) + "/" + strconv.Itoa(k)
+						pinned := key + "/pinned"
+
+						val := w<<32 | seq
+						c.Set(key, val)
+						c.SetWithTTL(pinned, val, NoExpiration)
+						last[k] = val
+
+						if got, ok := c.Get(key); ok && got != last[k] {
+							t.Errorf("%s: expected %d or a miss, got %d", key, last[k], got)
+							return
+						}
+						if got, ok := c.Get(pinned); !ok || got != last[k] {
+							t.Errorf("%s: expected live entry %d, got %d, %v", pinned, last[k], got, ok)
+							return
+						}
+					}
+				}()
+			}
+			wg.Wait()
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package mcachehttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/moorzeen/mcache"
+)
+
+type response struct {
+	Count   int     `json:"count"`
+	Stats   stats   `json:"stats"`
+	Entries []entry `json:"entries"`
+}
+
+type stats struct {
+	Hits        uint64  `json:"hits"`
+	Misses      uint64  `json:"misses"`
+	HitRatio    float64 `json:"hit_ratio"`
+	Expirations uint64  `json:"expirations"`
+	Evictions   uint64  `json:"evictions"`
+
+	LoadsInitiated    uint64 `json:"loads_initiated"`
+	LoadsDeduplicated uint64 `json:"loads_deduplicated"`
+	LoadErrors        uint64 `json:"load_errors"`
+}
+
+type entry struct {
+	Key    string          `json:"key"`
+	Value  json.RawMessage `json:"value,omitempty"`
+	Error  string          `json:"error,omitempty"`
+	Expiry time.Time       `json:"expiry,omitzero"`
+}
+
+func Handler[K comparable, V any](c *mcache.Cache[K, V]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		snapshot := c.Snapshot()
+		st := c.Stats()
+
+		resp := response{
+			Count: len(snapshot),
+			Stats: stats{
+				Hits:        st.Hits,
+				Misses:      st.Misses,
+				HitRatio:    st.HitRatio,
+				Expirations: st.Expirations,
+				Evictions:   st.Evictions,
+
+				LoadsInitiated:    st.LoadsInitiated,
+				LoadsDeduplicated: st.LoadsDeduplicated,
+				LoadErrors:        st.LoadErrors,
+			},
+			Entries: make([]entry, 0, len(snapshot)),
+		}
+
+		// Keys are rendered with fmt so they are always shown; a value that
+		// cannot be marshaled is replaced by its error.
+		for key, e := range snapshot {
+			ent := entry{Key: fmt.Sprint(key), Expiry: e.ExpiryTime}
+			if data, err := json.Marshal(e.Value); err != nil {
+				ent.Error = err.Error()
+			} else {
+				ent.Value = data
+			}
+			resp.Entries = append(resp.Entries, ent)
+		}
+
+		slices.SortFunc(resp.Entries, func(a, b entry) int {
+			return strings.Compare(a.Key, b.Key)
+		})
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodHead {
+			return
+		}
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(resp)
+	})
+}
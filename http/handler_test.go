@@ -0,0 +1,96 @@
+package mcachehttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/moorzeen/mcache"
+)
+
+func serve(t *testing.T, h http.Handler, method string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, "/debug/cache", nil))
+	return rec
+}
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder) response {
+	t.Helper()
+
+	var resp response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response %q: %v", rec.Body.String(), err)
+	}
+	return resp
+}
+
+func TestHandler(t *testing.T) {
+	c := mcache.NewCache(mcache.WithTTL[string, int](time.Hour))
+	defer c.Close()
+
+	c.Set("b", 2)
+	c.SetWithTTL("a", 1, mcache.NoExpiration)
+	c.Get("a")
+	c.Get("missing")
+
+	rec := serve(t, Handler(c), http.MethodGet)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("expected JSON content type, got %q", ct)
+	}
+
+	resp := decode(t, rec)
+	if resp.Count != 2 || len(resp.Entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d, %d", resp.Count, len(resp.Entries))
+	}
+	if resp.Stats.Hits != 1 || resp.Stats.Misses != 1 {
+		t.Fatalf("expected 1 hit and 1 miss, got %+v", resp.Stats)
+	}
+
+	a, b := resp.Entries[0], resp.Entries[1]
+	if a.Key != "a" || string(a.Value) != "1" || !a.Expiry.IsZero() {
+		t.Fatalf("unexpected entry %+v", a)
+	}
+	if b.Key != "b" || string(b.Value) != "2" || b.Expiry.IsZero() {
+		t.Fatalf("unexpected entry %+v", b)
+	}
+}
+
+func TestHandler_UnmarshalableValues(t *testing.T) {
+	c := mcache.NewCache[int, chan int]()
+	defer c.Close()
+
+	c.Set(1, make(chan int))
+
+	resp := decode(t, serve(t, Handler(c), http.MethodGet))
+	if len(resp.Entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(resp.Entries))
+	}
+	if e := resp.Entries[0]; e.Key != "1" || e.Value != nil || e.Error == "" {
+		t.Fatalf("expected key with a value error, got %+v", e)
+	}
+}
+
+func TestHandler_ReadOnly(t *testing.T) {
+	c := mcache.NewCache[string, int]()
+	defer c.Close()
+
+	rec := serve(t, Handler(c), http.MethodPost)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rec.Code)
+	}
+	if allow := rec.Header().Get("Allow"); allow != "GET, HEAD" {
+		t.Fatalf("expected Allow header, got %q", allow)
+	}
+
+	rec = serve(t, Handler(c), http.MethodHead)
+	if rec.Code != http.StatusOK || rec.Body.Len() != 0 {
+		t.Fatalf("expected empty 200 for HEAD, got %d with %d bytes", rec.Code, rec.Body.Len())
+	}
+}
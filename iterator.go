@@ -0,0 +1,58 @@
+package mcache
+
+import "iter"
+
+type Iterator[K comparable, V any] struct {
+	keys   []K
+	values []V
+	pos    int
+}
+
+func (c *Cache[K, V]) Iterator() *Iterator[K, V] {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := c.clock.Now()
+	it := &Iterator[K, V]{
+		keys:   make([]K, 0, len(c.items)),
+		values: make([]V, 0, len(c.items)),
+		pos:    -1,
+	}
+
+	for k, item := range c.items {
+		if !item.expired(now) {
+			it.keys = append(it.keys, k)
+			it.values = append(it.values, item.value)
+		}
+	}
+
+	return it
+}
+
+func (it *Iterator[K, V]) Next() bool {
+	if it.pos < len(it.keys) {
+		it.pos++
+	}
+	return it.pos < len(it.keys)
+}
+
+func (it *Iterator[K, V]) Key() K {
+	return it.keys[it.pos]
+}
+
+func (it *Iterator[K, V]) Value() V {
+	return it.values[it.pos]
+}
+
+// All snapshots the cache when iteration starts, like Iterator, so the loop
+// body can call back into the cache without deadlocking.
+func (c *Cache[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		it := c.Iterator()
+		for it.Next() {
+			if !yield(it.Key(), it.Value()) {
+				return
+			}
+		}
+	}
+}
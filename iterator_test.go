@@ -0,0 +1,118 @@
+package mcache
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestIterator(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	want := map[string]int{"a": 1, "b": 2, "c": 3}
+	c.SetMany(want)
+	c.Set("old", 4)
+	advance(c, ttl)
+	c.SetMany(want)
+
+	it := c.Iterator()
+	got := map[string]int{}
+	for it.Next() {
+		got[it.Key()] = it.Value()
+	}
+
+	if !maps.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if it.Next() {
+		t.Fatal("expected an exhausted iterator to stay exhausted")
+	}
+}
+
+func TestIterator_Snapshot(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	it := c.Iterator()
+
+	// Writes during iteration must not block or change what is returned.
+	c.Set("b", 2)
+	c.Delete("a")
+
+	n := 0
+	for it.Next() {
+		if it.Key() != "a" || it.Value() != 1 {
+			t.Fatalf("expected the entry from creation time, got %q, %d", it.Key(), it.Value())
+		}
+		c.Set(it.Key(), 10)
+		n++
+	}
+
+	if n != 1 {
+		t.Fatalf("expected 1 entry, got %d", n)
+	}
+}
+
+func TestIterator_Empty(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	if c.Iterator().Next() {
+		t.Fatal("expected an empty cache to yield nothing")
+	}
+}
+
+func TestAll(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	want := map[string]int{"a": 1, "b": 2}
+	c.Set("old", 0)
+	advance(c, ttl)
+	c.SetMany(want)
+
+	if got := maps.Collect(c.All()); !maps.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestAll_Break(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.SetMany(map[string]int{"a": 1, "b": 2, "c": 3})
+
+	n := 0
+	for range c.All() {
+		n++
+		break
+	}
+	if n != 1 {
+		t.Fatalf("expected iteration to stop after break, got %d", n)
+	}
+}
+
+func TestAll_BodyWritesToCache(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.SetMany(map[string]int{"a": 1, "b": 2})
+
+	for k, v := range c.All() {
+		c.Set(k, v*10)
+		c.Set(k+"!", v)
+	}
+
+	if val, _ := c.Get("a"); val != 10 {
+		t.Fatalf("expected writes from the loop body to be applied, got %d", val)
+	}
+	if n := c.Count(); n != 4 {
+		t.Fatalf("expected entries added during iteration not to be visited, got %d entries", n)
+	}
+
+	// Each range statement takes a fresh snapshot.
+	if n := len(maps.Collect(c.All())); n != 4 {
+		t.Fatalf("expected a new iteration to see 4 entries, got %d", n)
+	}
+}
@@ -0,0 +1,15 @@
+package mcache
+
+import "time"
+
+// jittered randomizes ttl by up to ±jitter of its length. Callers must hold
+// the write lock, since the random source is not safe for concurrent use.
+func (c *Cache[K, V]) jittered(ttl time.Duration) time.Duration {
+	if c.jitter <= 0 {
+		return ttl
+	}
+
+	offset := (c.rnd.Float64()*2 - 1) * c.jitter * float64(ttl)
+
+	return ttl + time.Duration(offset)
+}
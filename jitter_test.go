@@ -0,0 +1,96 @@
+package mcache
+
+import (
+	"math/rand/v2"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func newJitterTestCache(fraction float64, seed uint64) *Cache[string, int] {
+	return newTestCache(
+		WithJitter[string, int](fraction),
+		WithRand[string, int](rand.New(rand.NewPCG(seed, seed))),
+		WithCleanupInterval[string, int](0),
+	)
+}
+
+func expiries(c *Cache[string, int], n int) []time.Time {
+	result := make([]time.Time, n)
+	for i := range n {
+		c.Set(strconv.Itoa(i), i)
+	}
+	for i := range n {
+		result[i] = c.items[strconv.Itoa(i)].expiryTime
+	}
+	return result
+}
+
+func TestJitter_SpreadsExpiry(t *testing.T) {
+	c := newJitterTestCache(0.2, 1)
+	defer c.Close()
+
+	now := c.clock.Now()
+	lo, hi := now.Add(ttl*8/10), now.Add(ttl*12/10)
+	distinct := map[time.Time]bool{}
+
+	for _, exp := range expiries(c, 100) {
+		if exp.Before(lo) || exp.After(hi) {
+			t.Fatalf("expiry %v outside [%v, %v]", exp.Sub(now), lo.Sub(now), hi.Sub(now))
+		}
+		distinct[exp] = true
+	}
+
+	if len(distinct) < 50 {
+		t.Fatalf("expected expiries to be spread out, got %d distinct values", len(distinct))
+	}
+}
+
+func TestJitter_Deterministic(t *testing.T) {
+	a := newJitterTestCache(0.5, 42)
+	defer a.Close()
+	b := newJitterTestCache(0.5, 42)
+	defer b.Close()
+
+	ea, eb := expiries(a, 20), expiries(b, 20)
+	for i := range ea {
+		if !ea[i].Equal(eb[i]) {
+			t.Fatalf("expected identical expiries for the same seed, differ at %d", i)
+		}
+	}
+}
+
+func TestJitter_Disabled(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	want := c.clock.Now().Add(ttl)
+	for _, exp := range expiries(c, 10) {
+		if !exp.Equal(want) {
+			t.Fatalf("expected exact expiry without jitter, got %v", exp)
+		}
+	}
+}
+
+func TestJitter_NoExpirationUnaffected(t *testing.T) {
+	c := newJitterTestCache(0.5, 1)
+	defer c.Close()
+
+	c.SetWithTTL("a", 1, NoExpiration)
+
+	if ttl, _ := c.GetTTL("a"); ttl != NoExpiration {
+		t.Fatalf("expected entry to never expire, got %v", ttl)
+	}
+}
+
+func TestJitter_ClampedToFullTTL(t *testing.T) {
+	c := newJitterTestCache(5, 1)
+	defer c.Close()
+
+	now := c.clock.Now()
+	for _, exp := range expiries(c, 100) {
+		if exp.Before(now) || exp.After(now.Add(2*ttl)) {
+			t.Fatalf("expected jitter to be clamped to the ttl, got %v", exp.Sub(now))
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package mcache
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+type LoadingCache[K comparable, V any] struct {
+	*Cache[K, V]
+	loader   func(key K) (V, error)
+	failures *Cache[K, error]
+}
+
+func NewLoadingCache[K comparable, V any](loader func(key K) (V, error), opts ...Option[K, V]) *LoadingCache[K, V] {
+	o := defaultOptions[K, V]()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	c := &LoadingCache[K, V]{
+		Cache:  NewCache(opts...),
+		loader: loader,
+	}
+
+	if o.negativeTTL > 0 {
+		c.failures = NewCache(
+			WithTTL[K, error](o.negativeTTL),
+			WithClock[K, error](o.clock),
+		)
+	}
+
+	return c
+}
+
+func (c *LoadingCache[K, V]) Get(key K) (V, error) {
+	val, _, err := c.getWithStale(context.Background(), key)
+	return val, err
+}
+
+func (c *LoadingCache[K, V]) GetCtx(ctx context.Context, key K) (V, error) {
+	val, _, err := c.getWithStale(ctx, key)
+	return val, err
+}
+
+func (c *LoadingCache[K, V]) GetWithStale(key K) (V, bool, error) {
+	return c.getWithStale(context.Background(), key)
+}
+
+// getWithStale serves an entry within its grace period as stale and reloads
+// it in the background, so callers do not wait for the loader.
+func (c *LoadingCache[K, V]) getWithStale(ctx context.Context, key K) (V, bool, error) {
+	loader := func(context.Context) (V, error) {
+		return c.load(key)
+	}
+
+	if c.grace > 0 {
+		if val, stale, ok := c.stale(key); ok && stale {
+			c.stats.hit(false)
+			c.refresh(key, loader)
+			return val, true, nil
+		}
+	}
+
+	// A cached failure is returned here rather than by the loader, so it is
+	// not counted as another load error.
+	if c.failures != nil && !c.Has(key) {
+		if err, ok := c.failures.Peek(key); ok {
+			c.stats.hit(false)
+			var zero V
+			return zero, false, err
+		}
+	}
+
+	val, err := c.GetOrComputeCtx(ctx, key, loader)
+	return val, false, err
+}
+
+func (c *LoadingCache[K, V]) Warm(keys []K) error {
+	errs := make([]error, len(keys))
+
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = c.Get(key)
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+func (c *LoadingCache[K, V]) load(key K) (V, error) {
+	val, err := c.loader(key)
+	if err != nil && c.failures != nil {
+		c.failures.Set(key, err)
+	}
+
+	return val, err
+}
+
+func (c *LoadingCache[K, V]) Close() {
+	c.Cache.Close()
+	if c.failures != nil {
+		c.failures.Close()
+	}
+}
@@ -0,0 +1,528 @@
+package mcache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestLoadingCache(loader func(key string) (int, error), opts ...Option[string, int]) *LoadingCache[string, int] {
+	opts = append([]Option[string, int]{
+		WithTTL[string, int](ttl),
+		WithCleanupInterval[string, int](cleanupInterval),
+		WithClock[string, int](newFakeClock()),
+	}, opts...)
+	return NewLoadingCache(loader, opts...)
+}
+
+func TestLoadingCache_Get(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestLoadingCache(func(key string) (int, error) {
+		calls.Add(1)
+		return len(key), nil
+	})
+	defer c.Close()
+
+	for range 2 {
+		val, err := c.Get("abc")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if val != 3 {
+			t.Fatalf("expected 3, got %d", val)
+		}
+	}
+
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("expected loader to run once, ran %d times", n)
+	}
+	if val, ok := c.Peek("abc"); !ok || val != 3 {
+		t.Fatalf("expected loaded value to be cached, got %d, %v", val, ok)
+	}
+}
+
+func TestLoadingCache_ReloadsAfterExpiry(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestLoadingCache(func(key string) (int, error) {
+		return int(calls.Add(1)), nil
+	})
+	defer c.Close()
+
+	c.Get("a")
+	advance(c.Cache, ttl)
+
+	val, err := c.Get("a")
+	if err != nil || val != 2 {
+		t.Fatalf("expected reloaded value 2, got %d, %v", val, err)
+	}
+}
+
+func TestLoadingCache_ErrorNotCached(t *testing.T) {
+	errLoad := errors.New("load failed")
+	var calls atomic.Int32
+	c := newTestLoadingCache(func(key string) (int, error) {
+		calls.Add(1)
+		return 0, errLoad
+	})
+	defer c.Close()
+
+	for range 2 {
+		if _, err := c.Get("a"); !errors.Is(err, errLoad) {
+			t.Fatalf("expected %v, got %v", errLoad, err)
+		}
+	}
+
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("expected loader to be retried, ran %d times", n)
+	}
+	if c.Has("a") {
+		t.Fatal("expected failed load not to be cached")
+	}
+}
+
+func TestLoadingCache_Deduplicates(t *testing.T) {
+	var (
+		calls   atomic.Int32
+		release = make(chan struct{})
+		wg      sync.WaitGroup
+	)
+
+	c := newTestLoadingCache(func(key string) (int, error) {
+		calls.Add(1)
+		<-release
+		return 42, nil
+	})
+	defer c.Close()
+
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			val, err := c.Get("a")
+			if err != nil || val != 42 {
+				t.Errorf("expected 42, got %d, %v", val, err)
+			}
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("expected loader to run once, ran %d times", n)
+	}
+}
+
+func TestLoadingCache_SetBypassesLoader(t *testing.T) {
+	c := newTestLoadingCache(func(key string) (int, error) {
+		t.Fatal("loader should not run for a stored key")
+		return 0, nil
+	})
+	defer c.Close()
+
+	c.Set("a", 1)
+
+	if val, err := c.Get("a"); err != nil || val != 1 {
+		t.Fatalf("expected 1, got %d, %v", val, err)
+	}
+}
+
+func TestLoadingCache_NegativeTTL(t *testing.T) {
+	errLoad := errors.New("load failed")
+	var (
+		calls atomic.Int32
+		fail  atomic.Bool
+	)
+	fail.Store(true)
+
+	clock := newFakeClock()
+	c := newTestLoadingCache(func(key string) (int, error) {
+		calls.Add(1)
+		if fail.Load() {
+			return 0, errLoad
+		}
+		return 1, nil
+	}, WithClock[string, int](clock), WithNegativeTTL[string, int](ttl/2))
+	defer c.Close()
+
+	for range 3 {
+		if _, err := c.Get("a"); !errors.Is(err, errLoad) {
+			t.Fatalf("expected %v, got %v", errLoad, err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("expected failed load to be cached, loader ran %d times", n)
+	}
+	if c.Has("a") {
+		t.Fatal("expected failure not to be stored as a value")
+	}
+
+	fail.Store(false)
+	clock.Advance(ttl / 4)
+
+	if _, err := c.Get("a"); !errors.Is(err, errLoad) {
+		t.Fatalf("expected cached error within the window, got %v", err)
+	}
+
+	clock.Advance(ttl / 4)
+
+	val, err := c.Get("a")
+	if err != nil || val != 1 {
+		t.Fatalf("expected retry after the window to succeed, got %d, %v", val, err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("expected loader to be retried once, ran %d times", n)
+	}
+}
+
+func TestLoadingCache_NegativeTTLPerKey(t *testing.T) {
+	errLoad := errors.New("load failed")
+	c := newTestLoadingCache(func(key string) (int, error) {
+		if key == "bad" {
+			return 0, errLoad
+		}
+		return 1, nil
+	}, WithNegativeTTL[string, int](ttl))
+	defer c.Close()
+
+	if _, err := c.Get("bad"); !errors.Is(err, errLoad) {
+		t.Fatalf("expected %v, got %v", errLoad, err)
+	}
+	if val, err := c.Get("good"); err != nil || val != 1 {
+		t.Fatalf("expected other keys to load, got %d, %v", val, err)
+	}
+}
+
+func TestLoadingCache_SetOverridesNegativeEntry(t *testing.T) {
+	errLoad := errors.New("load failed")
+	c := newTestLoadingCache(func(key string) (int, error) {
+		return 0, errLoad
+	}, WithNegativeTTL[string, int](ttl))
+	defer c.Close()
+
+	c.Get("a")
+	c.Set("a", 5)
+
+	if val, err := c.Get("a"); err != nil || val != 5 {
+		t.Fatalf("expected stored value to win over cached error, got %d, %v", val, err)
+	}
+}
+
+func TestLoadingCache_CloseStopsNegativeCache(t *testing.T) {
+	c := newTestLoadingCache(func(key string) (int, error) {
+		return 0, nil
+	}, WithNegativeTTL[string, int](ttl))
+
+	c.Close()
+
+	if !c.failures.IsClosed() {
+		t.Fatal("expected Close to close the negative cache")
+	}
+}
+
+func TestLoadingCache_GetCtx(t *testing.T) {
+	release := make(chan struct{})
+	c := newTestLoadingCache(func(key string) (int, error) {
+		<-release
+		return 1, nil
+	})
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := c.GetCtx(ctx, "a"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	close(release)
+
+	if val, err := c.GetCtx(context.Background(), "b"); err != nil || val != 1 {
+		t.Fatalf("expected 1, got %d, %v", val, err)
+	}
+}
+
+func waitForValue(t *testing.T, c *LoadingCache[string, int], key string, want int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if val, ok := c.Peek(key); ok && val == want {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("expected %s to become %d", key, want)
+}
+
+func TestLoadingCache_StaleWhileRevalidate(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestLoadingCache(func(key string) (int, error) {
+		return int(calls.Add(1)), nil
+	}, WithGracePeriod[string, int](ttl))
+	defer c.Close()
+
+	c.Get("a")
+	advance(c.Cache, ttl)
+
+	val, stale, err := c.GetWithStale("a")
+	if err != nil || !stale || val != 1 {
+		t.Fatalf("expected stale 1, got %d, %v, %v", val, stale, err)
+	}
+
+	waitForValue(t, c, "a", 2)
+
+	val, stale, err = c.GetWithStale("a")
+	if err != nil || stale || val != 2 {
+		t.Fatalf("expected fresh 2, got %d, %v, %v", val, stale, err)
+	}
+}
+
+func TestLoadingCache_StaleRefreshDeduplicates(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	c := newTestLoadingCache(func(key string) (int, error) {
+		if calls.Add(1) > 1 {
+			<-release
+		}
+		return 1, nil
+	}, WithGracePeriod[string, int](ttl))
+	defer c.Close()
+
+	c.Get("a")
+	advance(c.Cache, ttl)
+
+	for range 10 {
+		if val, err := c.Get("a"); err != nil || val != 1 {
+			t.Fatalf("expected the stale value without waiting, got %d, %v", val, err)
+		}
+	}
+
+	close(release)
+	waitForValue(t, c, "a", 1)
+
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("expected a single background refresh, loader ran %d times", n)
+	}
+}
+
+func TestLoadingCache_PastGraceLoadsSynchronously(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestLoadingCache(func(key string) (int, error) {
+		return int(calls.Add(1)), nil
+	}, WithGracePeriod[string, int](ttl/2))
+	defer c.Close()
+
+	c.Get("a")
+	advance(c.Cache, ttl+ttl/2)
+
+	val, stale, err := c.GetWithStale("a")
+	if err != nil || stale || val != 2 {
+		t.Fatalf("expected a fresh load past the grace period, got %d, %v, %v", val, stale, err)
+	}
+}
+
+func TestLoadingCache_StaleRefreshError(t *testing.T) {
+	errLoad := errors.New("load failed")
+	var fail atomic.Bool
+	c := newTestLoadingCache(func(key string) (int, error) {
+		if fail.Load() {
+			return 0, errLoad
+		}
+		return 1, nil
+	}, WithGracePeriod[string, int](ttl))
+	defer c.Close()
+
+	c.Get("a")
+	fail.Store(true)
+	advance(c.Cache, ttl)
+
+	for range 3 {
+		if val, stale, err := c.GetWithStale("a"); err != nil || !stale || val != 1 {
+			t.Fatalf("expected the stale value while refreshes fail, got %d, %v, %v", val, stale, err)
+		}
+	}
+}
+
+func TestLoadingCache_Stats(t *testing.T) {
+	c := newTestLoadingCache(func(key string) (int, error) {
+		return 1, nil
+	}, WithGracePeriod[string, int](ttl))
+	defer c.Close()
+
+	c.Get("a")
+	c.Get("a")
+	advance(c.Cache, ttl)
+	c.Get("a")
+
+	if st := c.Stats(); st.Hits != 1 || st.Misses != 2 {
+		t.Fatalf("expected 1 hit and 2 misses, got %+v", st)
+	}
+}
+
+func TestLoadingCache_Warm(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestLoadingCache(func(key string) (int, error) {
+		calls.Add(1)
+		return len(key), nil
+	})
+	defer c.Close()
+
+	c.Set("a", 100)
+	keys := []string{"a", "bb", "ccc", "dddd"}
+
+	if err := c.Warm(keys); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, key := range keys[1:] {
+		if val, ok := c.Peek(key); !ok || val != len(key) {
+			t.Fatalf("expected %s to be loaded, got %d, %v", key, val, ok)
+		}
+	}
+	if val, _ := c.Peek("a"); val != 100 {
+		t.Fatalf("expected cached key to be left alone, got %d", val)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("expected 3 loads, got %d", n)
+	}
+}
+
+func TestLoadingCache_WarmErrors(t *testing.T) {
+	errA, errB := errors.New("a failed"), errors.New("b failed")
+	c := newTestLoadingCache(func(key string) (int, error) {
+		switch key {
+		case "a":
+			return 0, errA
+		case "b":
+			return 0, errB
+		}
+		return 1, nil
+	})
+	defer c.Close()
+
+	err := c.Warm([]string{"a", "b", "c"})
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Fatalf("expected both loader errors, got %v", err)
+	}
+	if !c.Has("c") {
+		t.Fatal("expected successful loads to be stored")
+	}
+}
+
+func TestLoadingCache_WarmRespectsMaxConcurrentLoads(t *testing.T) {
+	var running, peak atomic.Int32
+	c := newTestLoadingCache(func(key string) (int, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return 1, nil
+	}, WithMaxConcurrentLoads[string, int](2))
+	defer c.Close()
+
+	keys := make([]string, 10)
+	for i := range keys {
+		keys[i] = string(rune('a' + i))
+	}
+
+	if err := c.Warm(keys); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := c.Count(); n != len(keys) {
+		t.Fatalf("expected %d entries, got %d", len(keys), n)
+	}
+	if p := peak.Load(); p > 2 {
+		t.Fatalf("expected at most 2 concurrent loads, got %d", p)
+	}
+}
+
+func TestLoadingCache_LoadErrors(t *testing.T) {
+	errLoad := errors.New("load failed")
+	var (
+		mu     sync.Mutex
+		failed []string
+	)
+	c := newTestLoadingCache(func(key string) (int, error) {
+		return 0, errLoad
+	}, WithOnLoadError[string, int](func(key string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !errors.Is(err, errLoad) {
+			t.Errorf("expected %v, got %v", errLoad, err)
+		}
+		failed = append(failed, key)
+	}))
+	defer c.Close()
+
+	for range 2 {
+		if _, err := c.Get("a"); !errors.Is(err, errLoad) {
+			t.Fatalf("expected %v, got %v", errLoad, err)
+		}
+	}
+
+	if c.Has("a") {
+		t.Fatal("expected failed load not to be cached")
+	}
+	if st := c.Stats(); st.LoadErrors != 2 {
+		t.Fatalf("expected 2 load errors, got %d", st.LoadErrors)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(failed) != 2 || failed[0] != "a" || failed[1] != "a" {
+		t.Fatalf("expected the hook to run for each failed load, got %v", failed)
+	}
+}
+
+func TestLoadingCache_LoadErrorsNegativeTTL(t *testing.T) {
+	errLoad := errors.New("load failed")
+	c := newTestLoadingCache(func(key string) (int, error) {
+		return 0, errLoad
+	}, WithNegativeTTL[string, int](ttl))
+	defer c.Close()
+
+	for range 3 {
+		if _, err := c.Get("a"); !errors.Is(err, errLoad) {
+			t.Fatalf("expected %v, got %v", errLoad, err)
+		}
+	}
+
+	if st := c.Stats(); st.LoadErrors != 1 || st.Misses != 3 {
+		t.Fatalf("expected cached failures not to count as load errors, got %+v", st)
+	}
+}
+
+func TestLoadingCache_StaleRefreshPanic(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestLoadingCache(func(key string) (int, error) {
+		if calls.Add(1) > 1 {
+			panic("boom")
+		}
+		return 1, nil
+	}, WithGracePeriod[string, int](ttl))
+	defer c.Close()
+
+	c.Get("a")
+	advance(c.Cache, ttl)
+
+	if val, stale, err := c.GetWithStale("a"); err != nil || !stale || val != 1 {
+		t.Fatalf("expected stale 1, got %d, %v, %v", val, stale, err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for c.Stats().LoadErrors == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the panicking refresh to be counted as a load error")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,59 @@
+package mcache
+
+import "time"
+
+type EntryMeta struct {
+	Created    time.Time
+	LastAccess time.Time
+	ExpiryTime time.Time
+	Hits       uint64
+}
+
+// touch records a read. It only uses atomics, so it is safe under the read
+// lock.
+func (it *item[K, V]) touch(now time.Time) {
+	it.hits.Add(1)
+	it.accessed.Store(now.UnixNano())
+}
+
+func (it *item[K, V]) meta() EntryMeta {
+	m := EntryMeta{
+		Created:    it.created,
+		ExpiryTime: it.expiryTime,
+		Hits:       it.hits.Load(),
+	}
+	if ns := it.accessed.Load(); ns != 0 {
+		m.LastAccess = time.Unix(0, ns)
+	}
+
+	return m
+}
+
+func (c *Cache[K, V]) Metadata(key K) (EntryMeta, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	it, ok := c.items[key]
+	if !ok || it.expired(c.clock.Now()) {
+		return EntryMeta{}, false
+	}
+
+	return it.meta(), true
+}
+
+func (c *Cache[K, V]) GetWithMeta(key K) (V, EntryMeta, bool) {
+	c.mu.Lock()
+	defer c.unlock()
+
+	now := c.clock.Now()
+
+	it, ok := c.lookup(key, now)
+	c.stats.hit(ok)
+	if !ok {
+		var zero V
+		return zero, EntryMeta{}, false
+	}
+
+	c.access(it, now)
+	return it.value, it.meta(), true
+}
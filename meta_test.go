@@ -0,0 +1,146 @@
+package mcache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMetadata(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	created := c.clock.Now()
+	c.Set("a", 1)
+
+	meta, ok := c.Metadata("a")
+	if !ok {
+		t.Fatal("expected metadata for a live key")
+	}
+	if !meta.Created.Equal(created) || !meta.LastAccess.IsZero() || meta.Hits != 0 {
+		t.Fatalf("unexpected metadata before access: %+v", meta)
+	}
+
+	advance(c, 10*time.Millisecond)
+	c.Get("a")
+	advance(c, 10*time.Millisecond)
+	c.Get("a")
+
+	meta, _ = c.Metadata("a")
+	if !meta.Created.Equal(created) {
+		t.Fatalf("expected creation time to be kept, got %v", meta.Created)
+	}
+	if !meta.LastAccess.Equal(created.Add(20 * time.Millisecond)) {
+		t.Fatalf("expected last access at +20ms, got %v", meta.LastAccess.Sub(created))
+	}
+	if meta.Hits != 2 {
+		t.Fatalf("expected 2 hits, got %d", meta.Hits)
+	}
+}
+
+func TestMetadata_OverwriteKeepsCreated(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	created := c.clock.Now()
+	c.Set("a", 1)
+	advance(c, 10*time.Millisecond)
+	c.Set("a", 2)
+
+	if meta, _ := c.Metadata("a"); !meta.Created.Equal(created) {
+		t.Fatalf("expected overwrite to keep the creation time, got %v", meta.Created)
+	}
+}
+
+func TestMetadata_LockedGetPath(t *testing.T) {
+	c := newBoundedTestCache(2, WithClock[string, int](newFakeClock()))
+	defer c.Close()
+
+	c.Set("a", 1)
+	advance(c, time.Second)
+	c.Get("a")
+
+	meta, _ := c.Metadata("a")
+	if meta.Hits != 1 || !meta.LastAccess.Equal(c.clock.Now()) {
+		t.Fatalf("expected access to be tracked on bounded caches, got %+v", meta)
+	}
+}
+
+func TestMetadata_PeekDoesNotCount(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Peek("a")
+
+	if meta, _ := c.Metadata("a"); meta.Hits != 0 || !meta.LastAccess.IsZero() {
+		t.Fatalf("expected Peek not to count as an access, got %+v", meta)
+	}
+}
+
+func TestMetadata_MissingOrExpired(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	if _, ok := c.Metadata("missing"); ok {
+		t.Fatal("expected no metadata for a missing key")
+	}
+
+	c.Set("a", 1)
+	advance(c, ttl)
+
+	if _, ok := c.Metadata("a"); ok {
+		t.Fatal("expected no metadata for an expired key")
+	}
+}
+
+func TestGetWithMeta(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	created := c.clock.Now()
+	c.Set("a", 1)
+	advance(c, 10*time.Millisecond)
+
+	val, meta, ok := c.GetWithMeta("a")
+	if !ok || val != 1 {
+		t.Fatalf("expected 1, got %d, %v", val, ok)
+	}
+	if !meta.ExpiryTime.Equal(created.Add(ttl)) {
+		t.Fatalf("expected expiry at +ttl, got %v", meta.ExpiryTime.Sub(created))
+	}
+	if meta.Hits != 1 || !meta.LastAccess.Equal(c.clock.Now()) {
+		t.Fatalf("expected the read to be included in the metadata, got %+v", meta)
+	}
+	if st := c.Stats(); st.Hits != 1 {
+		t.Fatalf("expected GetWithMeta to count as a hit, got %d", st.Hits)
+	}
+}
+
+func TestGetWithMeta_NoExpiration(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.SetWithTTL("a", 1, NoExpiration)
+
+	if _, meta, _ := c.GetWithMeta("a"); !meta.ExpiryTime.IsZero() {
+		t.Fatalf("expected zero expiry time, got %v", meta.ExpiryTime)
+	}
+}
+
+func TestGetWithMeta_Missing(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	advance(c, ttl)
+
+	if _, _, ok := c.GetWithMeta("a"); ok {
+		t.Fatal("expected expired key to be missing")
+	}
+	if _, _, ok := c.GetWithMeta("missing"); ok {
+		t.Fatal("expected missing key to be missing")
+	}
+	if st := c.Stats(); st.Misses != 2 {
+		t.Fatalf("expected 2 misses, got %d", st.Misses)
+	}
+}
@@ -0,0 +1,101 @@
+package mcache
+
+import (
+	"cmp"
+	"slices"
+)
+
+func Increment[K comparable](c *Cache[K, int64], key K, delta int64) int64 {
+	c.mu.Lock()
+	defer c.unlock()
+
+	if it, ok := c.lookup(key, c.clock.Now()); ok {
+		value := it.value + delta
+		c.setAt(key, value, it.expiryTime)
+		return value
+	}
+
+	c.set(key, delta, c.ttl)
+	return delta
+}
+
+func CompareAndSwap[K, V comparable](c *Cache[K, V], key K, old, new V) bool {
+	c.mu.Lock()
+	defer c.unlock()
+
+	it, ok := c.lookup(key, c.clock.Now())
+	if !ok || it.value != old || c.closed {
+		return false
+	}
+
+	c.set(key, new, c.ttl)
+	return true
+}
+
+type Version uint64
+
+func (c *Cache[K, V]) nextVersion() uint64 {
+	c.versions++
+	return c.versions
+}
+
+func (c *Cache[K, V]) GetVersioned(key K) (V, Version, bool) {
+	c.mu.Lock()
+	defer c.unlock()
+
+	now := c.clock.Now()
+
+	it, ok := c.lookup(key, now)
+	c.stats.hit(ok)
+	if !ok {
+		var zero V
+		return zero, 0, false
+	}
+
+	c.access(it, now)
+	return it.value, Version(it.version), true
+}
+
+func (c *Cache[K, V]) SetIfVersion(key K, value V, v Version) bool {
+	c.mu.Lock()
+	defer c.unlock()
+
+	var current Version
+	if it, ok := c.lookup(key, c.clock.Now()); ok {
+		current = Version(it.version)
+	}
+	if current != v || c.closed {
+		return false
+	}
+
+	c.set(key, value, c.ttl)
+	_, ok := c.items[key]
+	return ok
+}
+
+func SortedKeys[K cmp.Ordered, V any](c *Cache[K, V]) []K {
+	keys := c.Keys()
+	slices.Sort(keys)
+
+	return keys
+}
+
+func (c *Cache[K, V]) Update(key K, fn func(old V, exists bool) (V, bool)) bool {
+	c.mu.Lock()
+	defer c.unlock()
+
+	var old V
+
+	it, exists := c.lookup(key, c.clock.Now())
+	if exists {
+		old = it.value
+	}
+
+	value, store := fn(old, exists)
+	if !store || c.closed {
+		return false
+	}
+
+	c.set(key, value, c.ttl)
+	return true
+}
@@ -0,0 +1,325 @@
+package mcache
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIncrement(t *testing.T) {
+	c := NewCache(WithTTL[string, int64](ttl))
+	defer c.Close()
+
+	if n := Increment(c, "a", 5); n != 5 {
+		t.Fatalf("expected 5, got %d", n)
+	}
+	if n := Increment(c, "a", -2); n != 3 {
+		t.Fatalf("expected 3, got %d", n)
+	}
+	if val, _ := c.Get("a"); val != 3 {
+		t.Fatalf("expected stored value 3, got %d", val)
+	}
+}
+
+func TestIncrement_KeepsExpiry(t *testing.T) {
+	c := NewCache(WithTTL[string, int64](ttl), WithClock[string, int64](newFakeClock()))
+	defer c.Close()
+
+	Increment(c, "a", 1)
+	advance(c, ttl/2)
+	Increment(c, "a", 1)
+	advance(c, ttl/2)
+
+	if n := Increment(c, "a", 1); n != 1 {
+		t.Fatalf("expected counter to restart after expiry, got %d", n)
+	}
+}
+
+func TestIncrement_Concurrent(t *testing.T) {
+	c := NewCache[string, int64]()
+	defer c.Close()
+
+	var wg sync.WaitGroup
+
+	for range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			Increment(c, "a", 1)
+		}()
+	}
+
+	wg.Wait()
+
+	if val, _ := c.Get("a"); val != 100 {
+		t.Fatalf("expected 100, got %d", val)
+	}
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	if CompareAndSwap(c, "a", 0, 1) {
+		t.Fatal("expected swap on missing key to fail")
+	}
+
+	c.Set("a", 1)
+
+	if CompareAndSwap(c, "a", 2, 3) {
+		t.Fatal("expected swap with mismatched value to fail")
+	}
+	if !CompareAndSwap(c, "a", 1, 3) {
+		t.Fatal("expected swap with matching value to succeed")
+	}
+	if val, _ := c.Get("a"); val != 3 {
+		t.Fatalf("expected 3, got %d", val)
+	}
+}
+
+func TestCompareAndSwap_FailureKeepsTTL(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	before, _ := c.GetTTL("a")
+	advance(c, 10*time.Millisecond)
+
+	CompareAndSwap(c, "a", 2, 3)
+
+	after, _ := c.GetTTL("a")
+	if after >= before {
+		t.Fatalf("expected failed swap not to reset ttl, before %v after %v", before, after)
+	}
+}
+
+func TestCompareAndSwap_Expired(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	advance(c, ttl)
+
+	if CompareAndSwap(c, "a", 1, 2) {
+		t.Fatal("expected swap on expired key to fail")
+	}
+}
+
+func TestSortedKeys(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("c", 3)
+	c.Set("a", 1)
+	c.Set("d", 4)
+	c.Set("b", 2)
+	advance(c, ttl)
+	c.Set("e", 5)
+	c.Set("b", 2)
+
+	if keys := SortedKeys(c); !slices.Equal(keys, []string{"b", "e"}) {
+		t.Fatalf("expected [b e], got %v", keys)
+	}
+}
+
+func TestSortedKeys_Ints(t *testing.T) {
+	c := NewCache[int, string]()
+	defer c.Close()
+
+	for _, k := range []int{5, -1, 3, 0} {
+		c.Set(k, "")
+	}
+
+	if keys := SortedKeys(c); !slices.Equal(keys, []int{-1, 0, 3, 5}) {
+		t.Fatalf("expected [-1 0 3 5], got %v", keys)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	c := NewCache(WithTTL[string, []int](ttl))
+	defer c.Close()
+
+	appendOne := func(old []int, exists bool) ([]int, bool) {
+		return append(old, len(old)+1), true
+	}
+
+	if !c.Update("a", appendOne) {
+		t.Fatal("expected update to store a new entry")
+	}
+	c.Update("a", appendOne)
+
+	val, _ := c.Get("a")
+	if len(val) != 2 || val[0] != 1 || val[1] != 2 {
+		t.Fatalf("expected [1 2], got %v", val)
+	}
+}
+
+func TestUpdate_Skip(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+
+	stored := c.Update("a", func(old int, exists bool) (int, bool) {
+		if !exists || old != 1 {
+			t.Errorf("expected existing value 1, got %d, %v", old, exists)
+		}
+		return 0, false
+	})
+	if stored {
+		t.Fatal("expected update to report nothing stored")
+	}
+	if val, _ := c.Get("a"); val != 1 {
+		t.Fatalf("expected value to be unchanged, got %d", val)
+	}
+}
+
+func TestUpdate_Concurrent(t *testing.T) {
+	c := NewCache[string, int]()
+	defer c.Close()
+
+	var wg sync.WaitGroup
+
+	for range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Update("a", func(old int, _ bool) (int, bool) {
+				return old + 1, true
+			})
+		}()
+	}
+
+	wg.Wait()
+
+	if val, _ := c.Get("a"); val != 100 {
+		t.Fatalf("expected 100, got %d", val)
+	}
+}
+
+func TestSetIfVersion(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	if _, v, ok := c.GetVersioned("a"); ok || v != 0 {
+		t.Fatalf("expected zero version for a missing key, got %v, %v", v, ok)
+	}
+	if !c.SetIfVersion("a", 1, 0) {
+		t.Fatal("expected zero version to store an absent key")
+	}
+	if c.SetIfVersion("a", 2, 0) {
+		t.Fatal("expected zero version not to overwrite an existing key")
+	}
+
+	val, v1, ok := c.GetVersioned("a")
+	if !ok || val != 1 || v1 == 0 {
+		t.Fatalf("expected 1 with a version, got %d, %v, %v", val, v1, ok)
+	}
+
+	c.Set("a", 1)
+	if c.SetIfVersion("a", 3, v1) {
+		t.Fatal("expected a stale version to be rejected after another write")
+	}
+
+	_, v2, _ := c.GetVersioned("a")
+	if v2 == v1 {
+		t.Fatal("expected every write to change the version")
+	}
+	if !c.SetIfVersion("a", 3, v2) {
+		t.Fatal("expected the current version to be accepted")
+	}
+	if val, _ := c.Peek("a"); val != 3 {
+		t.Fatalf("expected 3, got %d", val)
+	}
+}
+
+func TestSetIfVersion_DeletedKey(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	_, v, _ := c.GetVersioned("a")
+	c.Delete("a")
+	c.Set("a", 1)
+
+	if c.SetIfVersion("a", 2, v) {
+		t.Fatal("expected a recreated key not to reuse its old version")
+	}
+}
+
+func TestSetIfVersion_Expired(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	_, v, _ := c.GetVersioned("a")
+	advance(c, ttl)
+
+	if c.SetIfVersion("a", 2, v) {
+		t.Fatal("expected the version of an expired entry to be rejected")
+	}
+	if !c.SetIfVersion("a", 2, 0) {
+		t.Fatal("expected an expired key to count as absent")
+	}
+}
+
+func TestSetIfVersion_Clone(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	_, v, _ := c.GetVersioned("a")
+
+	clone := c.Clone()
+	defer clone.Close()
+
+	if !clone.SetIfVersion("a", 2, v) {
+		t.Fatal("expected the clone to keep entry versions")
+	}
+	if _, cv, _ := clone.GetVersioned("a"); cv <= v {
+		t.Fatalf("expected the clone to continue the version sequence, got %v after %v", cv, v)
+	}
+}
+
+func TestIncrement_ChangesVersion(t *testing.T) {
+	c := NewCache(WithTTL[string, int64](ttl))
+	defer c.Close()
+
+	Increment(c, "a", 1)
+	_, v, _ := c.GetVersioned("a")
+	Increment(c, "a", 1)
+
+	if c.SetIfVersion("a", 10, v) {
+		t.Fatal("expected Increment to invalidate the previous version")
+	}
+	if val, _ := c.Get("a"); val != 2 {
+		t.Fatalf("expected the increment to be kept, got %d", val)
+	}
+}
+
+func TestIncrement_RecomputesCost(t *testing.T) {
+	c := NewCache(WithCoster(func(key string, value int64) int64 {
+		return value
+	}))
+	defer c.Close()
+
+	Increment(c, "a", 2)
+	Increment(c, "a", 3)
+
+	if n := c.Cost(); n != 5 {
+		t.Fatalf("expected cost 5, got %d", n)
+	}
+}
+
+func TestIncrement_Closed(t *testing.T) {
+	c := NewCache[string, int64]()
+	Increment(c, "a", 1)
+	c.Close()
+
+	Increment(c, "a", 1)
+
+	if val, _ := c.Peek("a"); val != 1 {
+		t.Fatalf("expected a closed cache not to be modified, got %d", val)
+	}
+}
@@ -0,0 +1,183 @@
+package mcache
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+type Option[K comparable, V any] func(*options[K, V])
+
+type options[K comparable, V any] struct {
+	ttl                time.Duration
+	cleanupInterval    time.Duration
+	cleanupIntervalSet bool
+	cleanupBatch       int
+	grace              time.Duration
+	slidingTTL         bool
+	maxEntries         int
+	maxCost            int64
+	coster             func(key K, value V) int64
+	policy             EvictionPolicy
+	onEvict            func(key K, value V, reason EvictReason)
+	clock              Clock
+	computeHook        ComputeHook[K]
+	loadStripes        int
+	maxLoads           int
+	negativeTTL        time.Duration
+	promotionTTL       time.Duration
+	writeBehind        int
+	onStoreError       func(key K, err error)
+	jitter             float64
+	rnd                *rand.Rand
+	eventBuffer        int
+	shardHash          func(key K) uint64
+	onLoadError        func(key K, err error)
+}
+
+func defaultOptions[K comparable, V any]() options[K, V] {
+	return options[K, V]{
+		ttl:         NoExpiration,
+		clock:       realClock{},
+		loadStripes: defaultLoadStripes,
+		eventBuffer: eventBufferSize,
+	}
+}
+
+func WithTTL[K comparable, V any](ttl time.Duration) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.ttl = ttl
+	}
+}
+
+func WithCleanupInterval[K comparable, V any](interval time.Duration) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.cleanupInterval = interval
+		o.cleanupIntervalSet = true
+	}
+}
+
+func WithCleanupBatch[K comparable, V any](n int) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.cleanupBatch = n
+	}
+}
+
+func WithGracePeriod[K comparable, V any](d time.Duration) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.grace = d
+	}
+}
+
+func WithSlidingTTL[K comparable, V any]() Option[K, V] {
+	return func(o *options[K, V]) {
+		o.slidingTTL = true
+	}
+}
+
+func WithMaxEntries[K comparable, V any](n int) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.maxEntries = n
+	}
+}
+
+func WithMaxCost[K comparable, V any](maxCost int64) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.maxCost = maxCost
+	}
+}
+
+func WithCoster[K comparable, V any](coster func(key K, value V) int64) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.coster = coster
+	}
+}
+
+func WithEvictionPolicy[K comparable, V any](policy EvictionPolicy) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.policy = policy
+	}
+}
+
+func WithOnEvict[K comparable, V any](fn func(key K, value V, reason EvictReason)) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.onEvict = fn
+	}
+}
+
+func WithClock[K comparable, V any](clock Clock) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.clock = clock
+	}
+}
+
+func WithComputeHook[K comparable, V any](hook ComputeHook[K]) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.computeHook = hook
+	}
+}
+
+func WithOnLoadError[K comparable, V any](fn func(key K, err error)) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.onLoadError = fn
+	}
+}
+
+func WithLoadStripes[K comparable, V any](n int) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.loadStripes = n
+	}
+}
+
+func WithMaxConcurrentLoads[K comparable, V any](n int) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.maxLoads = n
+	}
+}
+
+func WithEventBuffer[K comparable, V any](n int) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.eventBuffer = n
+	}
+}
+
+func WithShardHash[K comparable, V any](hash func(key K) uint64) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.shardHash = hash
+	}
+}
+
+func WithNegativeTTL[K comparable, V any](ttl time.Duration) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.negativeTTL = ttl
+	}
+}
+
+func WithJitter[K comparable, V any](fraction float64) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.jitter = fraction
+	}
+}
+
+func WithRand[K comparable, V any](rnd *rand.Rand) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.rnd = rnd
+	}
+}
+
+func WithPromotionTTL[K comparable, V any](ttl time.Duration) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.promotionTTL = ttl
+	}
+}
+
+func WithWriteBehind[K comparable, V any](queueSize int) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.writeBehind = queueSize
+	}
+}
+
+func WithOnStoreError[K comparable, V any](fn func(key K, err error)) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.onStoreError = fn
+	}
+}
@@ -0,0 +1,112 @@
+package mcache
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+func TestNewCache_Defaults(t *testing.T) {
+	c := NewCache[string, int]()
+	defer c.Close()
+
+	if c.ttl != NoExpiration {
+		t.Fatalf("expected default ttl NoExpiration, got %v", c.ttl)
+	}
+	if c.cleanupInterval != 0 {
+		t.Fatalf("expected no cleanup interval, got %v", c.cleanupInterval)
+	}
+
+	c.Set("a", 1)
+	if ttl, ok := c.GetTTL("a"); !ok || ttl != NoExpiration {
+		t.Fatalf("expected entry without expiration, got %v, %v", ttl, ok)
+	}
+}
+
+func TestWithTTL_NonPositiveMeansNoExpiration(t *testing.T) {
+	for _, d := range []time.Duration{0, -time.Second, NoExpiration} {
+		c := NewCache(WithTTL[string, int](d), WithClock[string, int](newFakeClock()))
+
+		c.Set("a", 1)
+		advance(c, time.Hour)
+
+		if val, ok := c.Get("a"); !ok || val != 1 {
+			t.Fatalf("ttl %v: expected entry to be kept, got %d, %v", d, val, ok)
+		}
+		if remaining, ok := c.GetTTL("a"); !ok || remaining != NoExpiration {
+			t.Fatalf("ttl %v: expected NoExpiration, got %v, %v", d, remaining, ok)
+		}
+		if c.cleanupInterval != 0 {
+			t.Fatalf("ttl %v: expected no cleanup goroutine, got interval %v", d, c.cleanupInterval)
+		}
+
+		c.Close()
+	}
+}
+
+func TestWithTTL(t *testing.T) {
+	c := NewCache(WithTTL[string, int](ttl), WithClock[string, int](newFakeClock()))
+	defer c.Close()
+
+	c.Set("a", 1)
+	advance(c, ttl)
+
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected key to expire after ttl")
+	}
+}
+
+func TestWithTTL_DefaultsCleanupInterval(t *testing.T) {
+	c := NewCache(WithTTL[string, int](time.Minute))
+	defer c.Close()
+
+	if c.cleanupInterval != time.Minute {
+		t.Fatalf("expected cleanup interval to default to ttl, got %v", c.cleanupInterval)
+	}
+}
+
+func TestWithCleanupInterval_Zero(t *testing.T) {
+	c := NewCache(
+		WithTTL[string, int](time.Minute),
+		WithCleanupInterval[string, int](0),
+	)
+	defer c.Close()
+
+	if c.cleanupInterval != 0 {
+		t.Fatalf("expected cleanup to stay disabled, got %v", c.cleanupInterval)
+	}
+}
+
+func TestOptions_LaterOverridesEarlier(t *testing.T) {
+	c := NewCache(
+		WithTTL[string, int](time.Second),
+		WithTTL[string, int](time.Minute),
+		WithMaxEntries[string, int](1),
+		WithMaxEntries[string, int](2),
+	)
+	defer c.Close()
+
+	if c.ttl != time.Minute {
+		t.Fatalf("expected last ttl to win, got %v", c.ttl)
+	}
+	if c.maxEntries != 2 {
+		t.Fatalf("expected last max entries to win, got %d", c.maxEntries)
+	}
+}
+
+func TestWithRand(t *testing.T) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	c := NewCache(WithRand[string, int](rnd))
+	defer c.Close()
+
+	if c.rnd != rnd {
+		t.Fatal("expected the supplied source to be used")
+	}
+
+	d := NewCache(WithRand[string, int](nil))
+	defer d.Close()
+
+	if d.rnd == nil {
+		t.Fatal("expected a nil source to fall back to the default")
+	}
+}
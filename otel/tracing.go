@@ -0,0 +1,33 @@
+package mcacheotel
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/moorzeen/mcache"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	spanName = "mcache.GetOrCompute"
+
+	keyAttr = attribute.Key("mcache.key")
+	hitAttr = attribute.Key("mcache.hit")
+)
+
+func WithTracing[K comparable, V any](tracer trace.Tracer) mcache.Option[K, V] {
+	return mcache.WithComputeHook[K, V](func(ctx context.Context, key K) (context.Context, func(hit bool, err error)) {
+		ctx, span := tracer.Start(ctx, spanName, trace.WithAttributes(keyAttr.String(fmt.Sprint(key))))
+
+		return ctx, func(hit bool, err error) {
+			span.SetAttributes(hitAttr.Bool(hit))
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}
+	})
+}
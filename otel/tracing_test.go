@@ -0,0 +1,71 @@
+package mcacheotel
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/moorzeen/mcache"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTracedCache(t *testing.T) (*mcache.Cache[string, int], *tracetest.SpanRecorder) {
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	t.Cleanup(func() { _ = tp.Shutdown(t.Context()) })
+
+	c := mcache.NewCache(WithTracing[string, int](tp.Tracer("test")))
+	t.Cleanup(c.Close)
+
+	return c, rec
+}
+
+func TestWithTracing(t *testing.T) {
+	c, rec := newTracedCache(t)
+
+	c.GetOrCompute("a", func() (int, error) { return 1, nil })
+	c.GetOrCompute("a", func() (int, error) { return 2, nil })
+
+	spans := rec.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+
+	for i, wantHit := range []bool{false, true} {
+		span := spans[i]
+		if span.Name() != spanName {
+			t.Fatalf("expected span name %q, got %q", spanName, span.Name())
+		}
+
+		attrs := map[string]string{}
+		for _, kv := range span.Attributes() {
+			attrs[string(kv.Key)] = kv.Value.Emit()
+		}
+		if attrs[string(keyAttr)] != "a" {
+			t.Fatalf("expected key attribute 'a', got %q", attrs[string(keyAttr)])
+		}
+		if got := attrs[string(hitAttr)]; got != strconv.FormatBool(wantHit) {
+			t.Fatalf("span %d: expected hit=%v, got %q", i, wantHit, got)
+		}
+	}
+}
+
+func TestWithTracing_Error(t *testing.T) {
+	c, rec := newTracedCache(t)
+
+	errLoad := errors.New("load failed")
+	c.GetOrCompute("a", func() (int, error) { return 0, errLoad })
+
+	spans := rec.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if st := spans[0].Status(); st.Code != codes.Error || st.Description != errLoad.Error() {
+		t.Fatalf("expected error status, got %+v", st)
+	}
+	if n := len(spans[0].Events()); n != 1 {
+		t.Fatalf("expected recorded error event, got %d events", n)
+	}
+}
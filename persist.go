@@ -0,0 +1,87 @@
+package mcache
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+)
+
+// errNotCreated is returned when restoring into a Cache that was not created
+// by NewCache, such as the zero value encoding/json allocates for a nil
+// *Cache field.
+var errNotCreated = errors.New("mcache: cache must be created with NewCache")
+
+type record[K comparable, V any] struct {
+	Key    K         `json:"key"`
+	Value  V         `json:"value"`
+	Expiry time.Time `json:"expiry,omitzero"`
+}
+
+func (c *Cache[K, V]) records() []record[K, V] {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := c.clock.Now()
+	records := make([]record[K, V], 0, len(c.items))
+
+	for k, it := range c.items {
+		if !it.expired(now) {
+			records = append(records, record[K, V]{Key: k, Value: it.value, Expiry: it.expiryTime})
+		}
+	}
+
+	return records
+}
+
+func (c *Cache[K, V]) restore(records []record[K, V], overwrite bool) error {
+	if c.items == nil || c.clock == nil {
+		return errNotCreated
+	}
+
+	c.mu.Lock()
+	defer c.unlock()
+
+	now := c.clock.Now()
+
+	for _, r := range records {
+		if !r.Expiry.IsZero() && !now.Before(r.Expiry) {
+			continue
+		}
+		if !overwrite {
+			if _, ok := c.lookup(r.Key, now); ok {
+				continue
+			}
+		}
+		c.setAt(r.Key, r.Value, r.Expiry)
+	}
+
+	return nil
+}
+
+func (c *Cache[K, V]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.records())
+}
+
+func (c *Cache[K, V]) UnmarshalJSON(data []byte) error {
+	var records []record[K, V]
+	if err := json.Unmarshal(data, &records); err != nil {
+		return err
+	}
+
+	return c.restore(records, true)
+}
+
+func (c *Cache[K, V]) SaveGob(w io.Writer) error {
+	return gob.NewEncoder(w).Encode(c.records())
+}
+
+func (c *Cache[K, V]) LoadGob(r io.Reader) error {
+	var records []record[K, V]
+	if err := gob.NewDecoder(r).Decode(&records); err != nil {
+		return err
+	}
+
+	return c.restore(records, true)
+}
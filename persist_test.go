@@ -0,0 +1,279 @@
+package mcache
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestJSON_RoundTrip(t *testing.T) {
+	src := newTestCache()
+	defer src.Close()
+
+	src.Set("a", 1)
+	src.SetWithTTL("b", 2, NoExpiration)
+
+	data, err := json.Marshal(src)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	dst := newTestCache()
+	defer dst.Close()
+
+	if err := json.Unmarshal(data, dst); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	if val, ok := dst.Get("a"); !ok || val != 1 {
+		t.Fatalf("expected a=1, got %d, %v", val, ok)
+	}
+	if val, ok := dst.Get("b"); !ok || val != 2 {
+		t.Fatalf("expected b=2, got %d, %v", val, ok)
+	}
+	if ttl, _ := dst.GetTTL("b"); ttl != NoExpiration {
+		t.Fatalf("expected b to never expire, got %v", ttl)
+	}
+}
+
+func TestJSON_UnmarshalNilField(t *testing.T) {
+	var v struct {
+		C *Cache[string, int]
+	}
+
+	err := json.Unmarshal([]byte(`{"C":[{"key":"a","value":1}]}`), &v)
+	if !errors.Is(err, errNotCreated) {
+		t.Fatalf("expected %v, got %v", errNotCreated, err)
+	}
+}
+
+func TestJSON_PreservesExpiry(t *testing.T) {
+	src := newTestCache()
+	defer src.Close()
+
+	expiry := src.clock.Now().Add(time.Hour)
+	src.SetWithExpiry("a", 1, expiry)
+
+	data, err := json.Marshal(src)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	dst := newTestCache(WithClock[string, int](src.clock))
+	defer dst.Close()
+
+	if err := json.Unmarshal(data, dst); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	if ttl, ok := dst.GetTTL("a"); !ok || ttl != time.Hour {
+		t.Fatalf("expected remaining ttl of 1h, got %v, %v", ttl, ok)
+	}
+}
+
+func TestJSON_SkipsExpiredOnLoad(t *testing.T) {
+	src := newTestCache()
+	defer src.Close()
+
+	src.Set("a", 1)
+	src.SetWithTTL("b", 2, NoExpiration)
+
+	data, err := json.Marshal(src)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	dst := newTestCache(WithClock[string, int](src.clock))
+	defer dst.Close()
+	advance(dst, ttl)
+
+	if err := json.Unmarshal(data, dst); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	if dst.Has("a") {
+		t.Fatal("expected expired entry to be skipped")
+	}
+	if !dst.Has("b") {
+		t.Fatal("expected non-expiring entry to be loaded")
+	}
+}
+
+func TestJSON_MarshalSkipsExpired(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	advance(c, ttl)
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(data) != "[]" {
+		t.Fatalf("expected empty list, got %s", data)
+	}
+}
+
+func TestJSON_MergesIntoExisting(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+
+	if err := json.Unmarshal([]byte(`[{"key":"b","value":20},{"key":"c","value":30}]`), c); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	want := map[string]int{"a": 1, "b": 20, "c": 30}
+	got := c.GetAll()
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestJSON_NonStringKeys(t *testing.T) {
+	src := NewCache[int, string]()
+	defer src.Close()
+
+	src.Set(1, "one")
+
+	data, err := json.Marshal(src)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	dst := NewCache[int, string]()
+	defer dst.Close()
+
+	if err := json.Unmarshal(data, dst); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if val, ok := dst.Get(1); !ok || val != "one" {
+		t.Fatalf("expected 1=one, got %q, %v", val, ok)
+	}
+}
+
+func TestJSON_InvalidInput(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+
+	if err := json.Unmarshal([]byte(`{"a":1}`), c); err == nil {
+		t.Fatal("expected error for invalid input")
+	}
+	if val, ok := c.Get("a"); !ok || val != 1 {
+		t.Fatal("expected contents to be unchanged after failed load")
+	}
+}
+
+type gobValue struct {
+	Name string
+	Tags []string
+}
+
+func TestGob_RoundTrip(t *testing.T) {
+	src := NewCache[string, gobValue]()
+	defer src.Close()
+
+	src.Set("a", gobValue{Name: "alpha", Tags: []string{"x", "y"}})
+	src.SetWithExpiry("b", gobValue{Name: "beta"}, time.Now().Add(time.Hour))
+
+	var buf bytes.Buffer
+	if err := src.SaveGob(&buf); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	dst := NewCache[string, gobValue]()
+	defer dst.Close()
+
+	if err := dst.LoadGob(&buf); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	a, ok := dst.Get("a")
+	if !ok || a.Name != "alpha" || len(a.Tags) != 2 || a.Tags[1] != "y" {
+		t.Fatalf("unexpected value for a: %+v, %v", a, ok)
+	}
+	if ttl, _ := dst.GetTTL("a"); ttl != NoExpiration {
+		t.Fatalf("expected a to never expire, got %v", ttl)
+	}
+	if ttl, ok := dst.GetTTL("b"); !ok || ttl <= 0 || ttl > time.Hour {
+		t.Fatalf("expected b to keep its expiry, got %v, %v", ttl, ok)
+	}
+}
+
+func TestGob_SkipsExpiredOnLoad(t *testing.T) {
+	src := newTestCache()
+	defer src.Close()
+
+	src.Set("a", 1)
+	src.SetWithTTL("b", 2, NoExpiration)
+
+	var buf bytes.Buffer
+	if err := src.SaveGob(&buf); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	dst := newTestCache(WithClock[string, int](src.clock))
+	defer dst.Close()
+	advance(dst, ttl)
+
+	if err := dst.LoadGob(&buf); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	if dst.Has("a") {
+		t.Fatal("expected expired entry to be skipped")
+	}
+	if !dst.Has("b") {
+		t.Fatal("expected non-expiring entry to be loaded")
+	}
+}
+
+func TestGob_MergesIntoExisting(t *testing.T) {
+	src := newTestCache()
+	defer src.Close()
+
+	src.Set("b", 20)
+
+	var buf bytes.Buffer
+	if err := src.SaveGob(&buf); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	dst := newTestCache()
+	defer dst.Close()
+
+	dst.Set("a", 1)
+	dst.Set("b", 2)
+
+	if err := dst.LoadGob(&buf); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	if val, _ := dst.Get("a"); val != 1 {
+		t.Fatalf("expected a=1, got %d", val)
+	}
+	if val, _ := dst.Get("b"); val != 20 {
+		t.Fatalf("expected b=20, got %d", val)
+	}
+}
+
+func TestGob_InvalidInput(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	if err := c.LoadGob(bytes.NewReader([]byte("not gob"))); err == nil {
+		t.Fatal("expected error for invalid input")
+	}
+}
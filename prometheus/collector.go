@@ -0,0 +1,54 @@
+package mcacheprom
+
+import (
+	"github.com/moorzeen/mcache"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type Source interface {
+	Count() int
+	Stats() mcache.Stats
+}
+
+type collector struct {
+	source Source
+
+	entries     *prometheus.Desc
+	hits        *prometheus.Desc
+	misses      *prometheus.Desc
+	evictions   *prometheus.Desc
+	expirations *prometheus.Desc
+}
+
+func NewCollector(source Source, namespace string) prometheus.Collector {
+	name := func(metric string) string {
+		return prometheus.BuildFQName(namespace, "cache", metric)
+	}
+
+	return &collector{
+		source:      source,
+		entries:     prometheus.NewDesc(name("entries"), "Number of non-expired entries in the cache.", nil, nil),
+		hits:        prometheus.NewDesc(name("hits_total"), "Number of lookups that found a live entry.", nil, nil),
+		misses:      prometheus.NewDesc(name("misses_total"), "Number of lookups that found no live entry.", nil, nil),
+		evictions:   prometheus.NewDesc(name("evictions_total"), "Number of entries evicted to respect a capacity limit.", nil, nil),
+		expirations: prometheus.NewDesc(name("expirations_total"), "Number of expired entries removed from the cache.", nil, nil),
+	}
+}
+
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.entries
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.evictions
+	ch <- c.expirations
+}
+
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	st := c.source.Stats()
+
+	ch <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(c.source.Count()))
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(st.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(st.Misses))
+	ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(st.Evictions))
+	ch <- prometheus.MustNewConstMetric(c.expirations, prometheus.CounterValue, float64(st.Expirations))
+}
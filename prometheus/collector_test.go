@@ -0,0 +1,70 @@
+package mcacheprom
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moorzeen/mcache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	c := mcache.NewCache(
+		mcache.WithTTL[string, int](time.Hour),
+		mcache.WithMaxEntries[string, int](2),
+	)
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("c", 3)
+	c.Get("b")
+	c.Get("missing")
+
+	expected := `
+# HELP app_cache_entries Number of non-expired entries in the cache.
+# TYPE app_cache_entries gauge
+app_cache_entries 2
+# HELP app_cache_evictions_total Number of entries evicted to respect a capacity limit.
+# TYPE app_cache_evictions_total counter
+app_cache_evictions_total 1
+# HELP app_cache_expirations_total Number of expired entries removed from the cache.
+# TYPE app_cache_expirations_total counter
+app_cache_expirations_total 0
+# HELP app_cache_hits_total Number of lookups that found a live entry.
+# TYPE app_cache_hits_total counter
+app_cache_hits_total 1
+# HELP app_cache_misses_total Number of lookups that found no live entry.
+# TYPE app_cache_misses_total counter
+app_cache_misses_total 1
+`
+	if err := testutil.CollectAndCompare(NewCollector(c, "app"), strings.NewReader(expected)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCollector_ShardedCache(t *testing.T) {
+	c := mcache.NewShardedCache[string, int](4)
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(NewCollector(c, "app"))
+
+	if n := testutil.CollectAndCount(NewCollector(c, "app")); n != 5 {
+		t.Fatalf("expected 5 metrics, got %d", n)
+	}
+
+	expected := `
+# HELP app_cache_entries Number of non-expired entries in the cache.
+# TYPE app_cache_entries gauge
+app_cache_entries 2
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected), "app_cache_entries"); err != nil {
+		t.Fatal(err)
+	}
+}
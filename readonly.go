@@ -0,0 +1,33 @@
+package mcache
+
+type ReadOnlyCache[K comparable, V any] struct {
+	c *Cache[K, V]
+}
+
+func (c *Cache[K, V]) ReadOnly() ReadOnlyCache[K, V] {
+	return ReadOnlyCache[K, V]{c: c}
+}
+
+func (r ReadOnlyCache[K, V]) Get(key K) (V, bool) {
+	return r.c.Get(key)
+}
+
+func (r ReadOnlyCache[K, V]) Has(key K) bool {
+	return r.c.Has(key)
+}
+
+func (r ReadOnlyCache[K, V]) Peek(key K) (V, bool) {
+	return r.c.Peek(key)
+}
+
+func (r ReadOnlyCache[K, V]) Keys() []K {
+	return r.c.Keys()
+}
+
+func (r ReadOnlyCache[K, V]) Count() int {
+	return r.c.Count()
+}
+
+func (r ReadOnlyCache[K, V]) GetAll() map[K]V {
+	return r.c.GetAll()
+}
@@ -0,0 +1,43 @@
+package mcache
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestReadOnly(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	ro := c.ReadOnly()
+
+	if val, ok := ro.Get("a"); !ok || val != 1 {
+		t.Fatalf("expected 1, got %d, %v", val, ok)
+	}
+	if val, ok := ro.Peek("a"); !ok || val != 1 {
+		t.Fatalf("expected 1, got %d, %v", val, ok)
+	}
+	if !ro.Has("a") || ro.Has("b") {
+		t.Fatal("expected Has to report only stored keys")
+	}
+
+	c.Set("b", 2)
+
+	if n := ro.Count(); n != 2 {
+		t.Fatalf("expected the view to see later writes, got count %d", n)
+	}
+	if keys := ro.Keys(); !slices.Equal(slices.Sorted(slices.Values(keys)), []string{"a", "b"}) {
+		t.Fatalf("expected keys a and b, got %v", keys)
+	}
+	if all := ro.GetAll(); !maps.Equal(all, map[string]int{"a": 1, "b": 2}) {
+		t.Fatalf("expected both entries, got %v", all)
+	}
+
+	advance(c, ttl)
+
+	if _, ok := ro.Get("a"); ok {
+		t.Fatal("expected expired entries to be hidden")
+	}
+}
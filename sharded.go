@@ -0,0 +1,396 @@
+package mcache
+
+import (
+	"context"
+	"hash/maphash"
+	"math/rand/v2"
+	"slices"
+	"time"
+)
+
+type ShardedCache[K comparable, V any] struct {
+	shards []*Cache[K, V]
+	seed   maphash.Seed
+	hash   func(key K) uint64
+}
+
+func NewShardedCache[K comparable, V any](shards int, opts ...Option[K, V]) *ShardedCache[K, V] {
+	if shards < 1 {
+		shards = 1
+	}
+
+	o := defaultOptions[K, V]()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	sc := &ShardedCache[K, V]{
+		shards: make([]*Cache[K, V], shards),
+		seed:   maphash.MakeSeed(),
+		hash:   o.shardHash,
+	}
+
+	for i := range sc.shards {
+		shardOpts := opts
+		if o.rnd != nil {
+			// A *rand.Rand is not safe for concurrent use, so each shard gets
+			// its own source, seeded from the given one to stay reproducible.
+			rnd := rand.New(rand.NewPCG(o.rnd.Uint64(), o.rnd.Uint64()))
+			shardOpts = append(slices.Clip(opts), WithRand[K, V](rnd))
+		}
+		sc.shards[i] = NewCache(shardOpts...)
+	}
+
+	return sc
+}
+
+func (sc *ShardedCache[K, V]) index(key K) int {
+	if sc.hash != nil {
+		return int(sc.hash(key) % uint64(len(sc.shards)))
+	}
+	return int(maphash.Comparable(sc.seed, key) % uint64(len(sc.shards)))
+}
+
+func (sc *ShardedCache[K, V]) shard(key K) *Cache[K, V] {
+	return sc.shards[sc.index(key)]
+}
+
+// splitKeys groups keys by shard, so batch operations take each shard's lock
+// once.
+func (sc *ShardedCache[K, V]) splitKeys(keys []K) [][]K {
+	groups := make([][]K, len(sc.shards))
+	for _, k := range keys {
+		i := sc.index(k)
+		groups[i] = append(groups[i], k)
+	}
+
+	return groups
+}
+
+func (sc *ShardedCache[K, V]) splitItems(items map[K]V) []map[K]V {
+	groups := make([]map[K]V, len(sc.shards))
+	for k, v := range items {
+		i := sc.index(k)
+		if groups[i] == nil {
+			groups[i] = make(map[K]V)
+		}
+		groups[i][k] = v
+	}
+
+	return groups
+}
+
+func (sc *ShardedCache[K, V]) Close() {
+	for _, c := range sc.shards {
+		c.Close()
+	}
+}
+
+func (sc *ShardedCache[K, V]) IsClosed() bool {
+	return sc.shards[0].IsClosed()
+}
+
+func (sc *ShardedCache[K, V]) TTL() time.Duration {
+	return sc.shards[0].TTL()
+}
+
+func (sc *ShardedCache[K, V]) SetDefaultTTL(ttl time.Duration) {
+	for _, s := range sc.shards {
+		s.SetDefaultTTL(ttl)
+	}
+}
+
+func (sc *ShardedCache[K, V]) Set(key K, value V) {
+	sc.shard(key).Set(key, value)
+}
+
+func (sc *ShardedCache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	sc.shard(key).SetWithTTL(key, value, ttl)
+}
+
+func (sc *ShardedCache[K, V]) SetWithExpiry(key K, value V, expiry time.Time) {
+	sc.shard(key).SetWithExpiry(key, value, expiry)
+}
+
+func (sc *ShardedCache[K, V]) SetNX(key K, value V) bool {
+	return sc.shard(key).SetNX(key, value)
+}
+
+func (sc *ShardedCache[K, V]) Replace(key K, value V) bool {
+	return sc.shard(key).Replace(key, value)
+}
+
+func (sc *ShardedCache[K, V]) SetValue(key K, value V) bool {
+	return sc.shard(key).SetValue(key, value)
+}
+
+func (sc *ShardedCache[K, V]) GetOrSet(key K, value V) (V, bool) {
+	return sc.shard(key).GetOrSet(key, value)
+}
+
+func (sc *ShardedCache[K, V]) GetOrCompute(key K, loader func() (V, error)) (V, error) {
+	return sc.shard(key).GetOrCompute(key, loader)
+}
+
+func (sc *ShardedCache[K, V]) GetOrComputeCtx(ctx context.Context, key K, loader func(ctx context.Context) (V, error)) (V, error) {
+	return sc.shard(key).GetOrComputeCtx(ctx, key, loader)
+}
+
+func (sc *ShardedCache[K, V]) GetOrSetFunc(key K, fn func() V) (V, bool) {
+	return sc.shard(key).GetOrSetFunc(key, fn)
+}
+
+func (sc *ShardedCache[K, V]) Get(key K) (V, bool) {
+	return sc.shard(key).Get(key)
+}
+
+func (sc *ShardedCache[K, V]) GetStale(key K) (V, bool, bool) {
+	return sc.shard(key).GetStale(key)
+}
+
+func (sc *ShardedCache[K, V]) GetTTL(key K) (time.Duration, bool) {
+	return sc.shard(key).GetTTL(key)
+}
+
+func (sc *ShardedCache[K, V]) Touch(key K) bool {
+	return sc.shard(key).Touch(key)
+}
+
+func (sc *ShardedCache[K, V]) Expire(key K, ttl time.Duration) bool {
+	return sc.shard(key).Expire(key, ttl)
+}
+
+func (sc *ShardedCache[K, V]) Persist(key K) bool {
+	return sc.shard(key).Persist(key)
+}
+
+func (sc *ShardedCache[K, V]) ExpireAt(key K, t time.Time) bool {
+	return sc.shard(key).ExpireAt(key, t)
+}
+
+func (sc *ShardedCache[K, V]) Peek(key K) (V, bool) {
+	return sc.shard(key).Peek(key)
+}
+
+func (sc *ShardedCache[K, V]) Has(key K) bool {
+	return sc.shard(key).Has(key)
+}
+
+func (sc *ShardedCache[K, V]) GetAndDelete(key K) (V, bool) {
+	return sc.shard(key).GetAndDelete(key)
+}
+
+func (sc *ShardedCache[K, V]) Release(key K) (V, bool) {
+	return sc.shard(key).Release(key)
+}
+
+func (sc *ShardedCache[K, V]) Delete(key K) {
+	sc.shard(key).Delete(key)
+}
+
+func (sc *ShardedCache[K, V]) Clear() {
+	for _, c := range sc.shards {
+		c.Clear()
+	}
+}
+
+func (sc *ShardedCache[K, V]) Count() int {
+	count := 0
+	for _, c := range sc.shards {
+		count += c.Count()
+	}
+
+	return count
+}
+
+func (sc *ShardedCache[K, V]) Len() int {
+	n := 0
+	for _, c := range sc.shards {
+		n += c.Len()
+	}
+
+	return n
+}
+
+func (sc *ShardedCache[K, V]) GetAll() map[K]V {
+	result := make(map[K]V)
+	for _, c := range sc.shards {
+		for k, v := range c.GetAll() {
+			result[k] = v
+		}
+	}
+
+	return result
+}
+
+func (sc *ShardedCache[K, V]) GetAllFunc(pred func(key K, value V) bool) map[K]V {
+	result := make(map[K]V)
+	for _, c := range sc.shards {
+		for k, v := range c.GetAllFunc(pred) {
+			result[k] = v
+		}
+	}
+
+	return result
+}
+
+func (sc *ShardedCache[K, V]) Keys() []K {
+	var keys []K
+	for _, c := range sc.shards {
+		keys = append(keys, c.Keys()...)
+	}
+
+	return keys
+}
+
+func (sc *ShardedCache[K, V]) Values() []V {
+	var values []V
+	for _, c := range sc.shards {
+		values = append(values, c.Values()...)
+	}
+
+	return values
+}
+
+func (sc *ShardedCache[K, V]) SetMany(items map[K]V) {
+	for i, group := range sc.splitItems(items) {
+		if group != nil {
+			sc.shards[i].SetMany(group)
+		}
+	}
+}
+
+func (sc *ShardedCache[K, V]) SetManyWithTTL(items map[K]V, ttl time.Duration) {
+	for i, group := range sc.splitItems(items) {
+		if group != nil {
+			sc.shards[i].SetManyWithTTL(group, ttl)
+		}
+	}
+}
+
+func (sc *ShardedCache[K, V]) SetManyEvicting(items map[K]V) []K {
+	var evicted []K
+	for i, group := range sc.splitItems(items) {
+		if group != nil {
+			evicted = append(evicted, sc.shards[i].SetManyEvicting(group)...)
+		}
+	}
+
+	return evicted
+}
+
+func (sc *ShardedCache[K, V]) GetMany(keys []K) map[K]V {
+	result := make(map[K]V, len(keys))
+	for i, group := range sc.splitKeys(keys) {
+		if group != nil {
+			for k, v := range sc.shards[i].GetMany(group) {
+				result[k] = v
+			}
+		}
+	}
+
+	return result
+}
+
+func (sc *ShardedCache[K, V]) GetManyOrDefault(keys []K, def V) map[K]V {
+	result := make(map[K]V, len(keys))
+	for i, group := range sc.splitKeys(keys) {
+		if group != nil {
+			for k, v := range sc.shards[i].GetManyOrDefault(group, def) {
+				result[k] = v
+			}
+		}
+	}
+
+	return result
+}
+
+func (sc *ShardedCache[K, V]) DeleteMany(keys []K) int {
+	n := 0
+	for i, group := range sc.splitKeys(keys) {
+		if group != nil {
+			n += sc.shards[i].DeleteMany(group)
+		}
+	}
+
+	return n
+}
+
+func (sc *ShardedCache[K, V]) DeleteFunc(pred func(key K, value V) bool) int {
+	n := 0
+	for _, c := range sc.shards {
+		n += c.DeleteFunc(pred)
+	}
+
+	return n
+}
+
+func (sc *ShardedCache[K, V]) DeleteExpired() int {
+	n := 0
+	for _, c := range sc.shards {
+		n += c.DeleteExpired()
+	}
+
+	return n
+}
+
+func (sc *ShardedCache[K, V]) Range(fn func(key K, value V) bool) {
+	more := true
+	for _, c := range sc.shards {
+		c.Range(func(key K, value V) bool {
+			more = fn(key, value)
+			return more
+		})
+		if !more {
+			return
+		}
+	}
+}
+
+func (sc *ShardedCache[K, V]) Snapshot() map[K]Entry[V] {
+	result := make(map[K]Entry[V])
+	for _, c := range sc.shards {
+		for k, e := range c.Snapshot() {
+			result[k] = e
+		}
+	}
+
+	return result
+}
+
+func (sc *ShardedCache[K, V]) CountLive() int {
+	return sc.Count()
+}
+
+func (sc *ShardedCache[K, V]) SetWithCost(key K, value V, cost int64) {
+	sc.shard(key).SetWithCost(key, value, cost)
+}
+
+func (sc *ShardedCache[K, V]) Cost() int64 {
+	var cost int64
+	for _, c := range sc.shards {
+		cost += c.Cost()
+	}
+
+	return cost
+}
+
+func (sc *ShardedCache[K, V]) Update(key K, fn func(old V, exists bool) (V, bool)) bool {
+	return sc.shard(key).Update(key, fn)
+}
+
+func (sc *ShardedCache[K, V]) GetVersioned(key K) (V, Version, bool) {
+	return sc.shard(key).GetVersioned(key)
+}
+
+func (sc *ShardedCache[K, V]) SetIfVersion(key K, value V, v Version) bool {
+	return sc.shard(key).SetIfVersion(key, value, v)
+}
+
+func (sc *ShardedCache[K, V]) Metadata(key K) (EntryMeta, bool) {
+	return sc.shard(key).Metadata(key)
+}
+
+func (sc *ShardedCache[K, V]) GetWithMeta(key K) (V, EntryMeta, bool) {
+	return sc.shard(key).GetWithMeta(key)
+}
@@ -0,0 +1,355 @@
+package mcache
+
+import (
+	"maps"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func newTestShardedCache() *ShardedCache[string, int] {
+	return NewShardedCache(4,
+		WithTTL[string, int](ttl),
+		WithCleanupInterval[string, int](cleanupInterval),
+		WithClock[string, int](newFakeClock()),
+	)
+}
+
+func TestShardedCache_SetGet(t *testing.T) {
+	c := newTestShardedCache()
+	defer c.Close()
+
+	for i := range 100 {
+		c.Set(strconv.Itoa(i), i)
+	}
+
+	for i := range 100 {
+		val, ok := c.Get(strconv.Itoa(i))
+		if !ok || val != i {
+			t.Fatalf("expected %d, got %d, %v", i, val, ok)
+		}
+	}
+}
+
+func TestShardedCache_GetAndDelete(t *testing.T) {
+	c := newTestShardedCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+
+	if val, ok := c.GetAndDelete("a"); !ok || val != 1 {
+		t.Fatalf("expected 1, got %d, %v", val, ok)
+	}
+	if val, ok := c.Release("b"); !ok || val != 2 {
+		t.Fatalf("expected 2, got %d, %v", val, ok)
+	}
+	if n := c.Count(); n != 0 {
+		t.Fatalf("expected both keys to be removed, got %d", n)
+	}
+}
+
+func TestShardedCache_GetAllFunc(t *testing.T) {
+	c := newTestShardedCache()
+	defer c.Close()
+
+	for i := range 100 {
+		c.Set(strconv.Itoa(i), i)
+	}
+
+	got := c.GetAllFunc(func(key string, value int) bool { return value%10 == 0 })
+	if len(got) != 10 {
+		t.Fatalf("expected 10 matches across shards, got %d", len(got))
+	}
+}
+
+func TestShardedCache_Distributes(t *testing.T) {
+	c := newTestShardedCache()
+	defer c.Close()
+
+	for i := range 100 {
+		c.Set(strconv.Itoa(i), i)
+	}
+
+	for i, shard := range c.shards {
+		if shard.Count() == 0 {
+			t.Fatalf("expected shard %d to hold entries", i)
+		}
+	}
+}
+
+func TestWithShardHash(t *testing.T) {
+	c := NewShardedCache(4, WithShardHash[int, int](func(key int) uint64 {
+		return uint64(key)
+	}))
+	defer c.Close()
+
+	for i := range 8 {
+		c.Set(i, i)
+	}
+
+	for i, shard := range c.shards {
+		keys := SortedKeys(shard)
+		if len(keys) != 2 || keys[0] != i || keys[1] != i+4 {
+			t.Fatalf("expected shard %d to hold %d and %d, got %v", i, i, i+4, keys)
+		}
+	}
+	if val, ok := c.Get(5); !ok || val != 5 {
+		t.Fatalf("expected 5, got %d, %v", val, ok)
+	}
+}
+
+func TestShardedCache_WithRand(t *testing.T) {
+	c := NewShardedCache(8,
+		WithTTL[string, int](ttl),
+		WithJitter[string, int](0.5),
+		WithRand[string, int](rand.New(rand.NewPCG(1, 1))),
+	)
+	defer c.Close()
+
+	for i, shard := range c.shards[1:] {
+		if shard.rnd == c.shards[0].rnd {
+			t.Fatalf("expected shard %d to have its own random source", i+1)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for w := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 200 {
+				c.Set(strconv.Itoa(w*1000+i), i)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestShardedCache_Aggregates(t *testing.T) {
+	c := newTestShardedCache()
+	defer c.Close()
+
+	for i := range 10 {
+		c.Set(strconv.Itoa(i), i)
+	}
+
+	if n := c.Count(); n != 10 {
+		t.Fatalf("expected count 10, got %d", n)
+	}
+	if n := c.Len(); n != 10 {
+		t.Fatalf("expected len 10, got %d", n)
+	}
+	if all := c.GetAll(); len(all) != 10 {
+		t.Fatalf("expected 10 items, got %d", len(all))
+	}
+	if keys := c.Keys(); len(keys) != 10 {
+		t.Fatalf("expected 10 keys, got %d", len(keys))
+	}
+	if values := c.Values(); len(values) != 10 {
+		t.Fatalf("expected 10 values, got %d", len(values))
+	}
+
+	c.Delete("0")
+
+	if n := c.Count(); n != 9 {
+		t.Fatalf("expected count 9, got %d", n)
+	}
+
+	c.Clear()
+
+	if n := c.Count(); n != 0 {
+		t.Fatalf("expected count 0, got %d", n)
+	}
+}
+
+func TestShardedCache_Batch(t *testing.T) {
+	c := newTestShardedCache()
+	defer c.Close()
+
+	items := make(map[string]int)
+	keys := make([]string, 0, 20)
+	for i := range 20 {
+		items[strconv.Itoa(i)] = i
+		keys = append(keys, strconv.Itoa(i))
+	}
+	c.SetMany(items)
+
+	if got := c.GetMany(append(keys, "missing")); !maps.Equal(got, items) {
+		t.Fatalf("expected all items, got %v", got)
+	}
+	if got := c.GetManyOrDefault([]string{"0", "missing"}, -1); !maps.Equal(got, map[string]int{"0": 0, "missing": -1}) {
+		t.Fatalf("expected a default for the missing key, got %v", got)
+	}
+	if n := len(c.Snapshot()); n != 20 {
+		t.Fatalf("expected 20 snapshot entries, got %d", n)
+	}
+
+	if n := c.DeleteMany(keys[:5]); n != 5 {
+		t.Fatalf("expected 5 deletions, got %d", n)
+	}
+	if n := c.DeleteFunc(func(key string, value int) bool { return value%2 == 0 }); n != 7 {
+		t.Fatalf("expected 7 even values to be deleted, got %d", n)
+	}
+	if n := c.CountLive(); n != 8 {
+		t.Fatalf("expected 8 entries left, got %d", n)
+	}
+
+	c.SetManyWithTTL(map[string]int{"x": 1}, ttl/2)
+	advance(c.shard("x"), ttl/2)
+	if n := c.shard("x").DeleteExpired(); n != 1 {
+		t.Fatalf("expected the short-lived entry to expire, got %d", n)
+	}
+}
+
+func TestShardedCache_Range(t *testing.T) {
+	c := newTestShardedCache()
+	defer c.Close()
+
+	for i := range 20 {
+		c.Set(strconv.Itoa(i), i)
+	}
+
+	seen := 0
+	c.Range(func(key string, value int) bool {
+		seen++
+		return true
+	})
+	if seen != 20 {
+		t.Fatalf("expected to visit 20 entries, got %d", seen)
+	}
+
+	seen = 0
+	c.Range(func(key string, value int) bool {
+		seen++
+		return seen < 3
+	})
+	if seen != 3 {
+		t.Fatalf("expected Range to stop across shards, visited %d", seen)
+	}
+}
+
+func TestShardedCache_LimitsPerShard(t *testing.T) {
+	c := NewShardedCache(4,
+		WithMaxEntries[int, int](2),
+		WithShardHash[int, int](func(key int) uint64 { return uint64(key) }),
+	)
+	defer c.Close()
+
+	evicted := c.SetManyEvicting(map[int]int{0: 0, 1: 1, 2: 2, 3: 3, 4: 4, 5: 5, 6: 6, 7: 7})
+	if len(evicted) != 0 {
+		t.Fatalf("expected each shard to hold 2 entries, got evictions %v", evicted)
+	}
+	if n := c.Count(); n != 8 {
+		t.Fatalf("expected shards x limit entries, got %d", n)
+	}
+
+	c.Set(8, 8)
+	if n := c.Count(); n != 8 || c.Has(0) && c.Has(4) {
+		t.Fatal("expected a full shard to evict even though others have room")
+	}
+}
+
+func TestShardedCache_Versioned(t *testing.T) {
+	c := newTestShardedCache()
+	defer c.Close()
+
+	c.SetWithCost("a", 1, 3)
+	if n := c.Cost(); n != 3 {
+		t.Fatalf("expected cost 3, got %d", n)
+	}
+
+	_, v, ok := c.GetVersioned("a")
+	if !ok || !c.SetIfVersion("a", 2, v) || c.SetIfVersion("a", 3, v) {
+		t.Fatal("expected versions to be checked on the key's shard")
+	}
+	if !c.Update("a", func(old int, exists bool) (int, bool) { return old + 1, exists }) {
+		t.Fatal("expected Update to reach the key's shard")
+	}
+	if val, meta, ok := c.GetWithMeta("a"); !ok || val != 3 || meta.ExpiryTime.IsZero() {
+		t.Fatalf("expected 3 with metadata, got %d, %+v, %v", val, meta, ok)
+	}
+}
+
+func TestShardedCache_Expired(t *testing.T) {
+	c := newTestShardedCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	advance(c.shards[0], ttl)
+
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected key to be expired")
+	}
+	if n := c.Count(); n != 0 {
+		t.Fatalf("expected count 0, got %d", n)
+	}
+}
+
+func TestShardedCache_Close(t *testing.T) {
+	c := newTestShardedCache()
+	c.Close()
+
+	if !c.IsClosed() {
+		t.Fatal("expected cache to be closed")
+	}
+	for i, shard := range c.shards {
+		if !shard.IsClosed() {
+			t.Fatalf("expected shard %d to be closed", i)
+		}
+	}
+}
+
+func TestShardedCache_Concurrency(t *testing.T) {
+	c := newTestShardedCache()
+	defer c.Close()
+
+	var wg sync.WaitGroup
+
+	for i := range 100 {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := strconv.Itoa(i % 26)
+			c.Set(key, i)
+			c.Get(key)
+			c.Count()
+		}(i)
+	}
+
+	wg.Wait()
+}
+
+func benchmarkSetGet(b *testing.B, set func(int, int), get func(int) (int, bool)) {
+	for i := range 1024 {
+		set(i, i)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			if i%4 == 0 {
+				set(i&1023, i)
+			} else {
+				get(i & 1023)
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkSetGet_SingleLock(b *testing.B) {
+	c := NewCache[int, int]()
+	defer c.Close()
+
+	benchmarkSetGet(b, c.Set, c.Get)
+}
+
+func BenchmarkSetGet_Sharded(b *testing.B) {
+	c := NewShardedCache[int, int](16)
+	defer c.Close()
+
+	benchmarkSetGet(b, c.Set, c.Get)
+}
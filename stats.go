@@ -0,0 +1,114 @@
+package mcache
+
+import "sync/atomic"
+
+type Stats struct {
+	Hits        uint64
+	Misses      uint64
+	HitRatio    float64
+	Expirations uint64
+	Evictions   uint64
+
+	LoadsInitiated    uint64
+	LoadsDeduplicated uint64
+	LoadErrors        uint64
+}
+
+type ShardStats struct {
+	Stats
+	Count int
+}
+
+type stats struct {
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+	expirations atomic.Uint64
+	evictions   atomic.Uint64
+
+	loadsInitiated    atomic.Uint64
+	loadsDeduplicated atomic.Uint64
+	loadErrors        atomic.Uint64
+}
+
+func (s *stats) hit(ok bool) {
+	if ok {
+		s.hits.Add(1)
+	} else {
+		s.misses.Add(1)
+	}
+}
+
+func (s *stats) snapshot() Stats {
+	st := Stats{
+		Hits:        s.hits.Load(),
+		Misses:      s.misses.Load(),
+		Expirations: s.expirations.Load(),
+		Evictions:   s.evictions.Load(),
+
+		LoadsInitiated:    s.loadsInitiated.Load(),
+		LoadsDeduplicated: s.loadsDeduplicated.Load(),
+		LoadErrors:        s.loadErrors.Load(),
+	}
+	st.computeHitRatio()
+
+	return st
+}
+
+func (s *stats) reset() {
+	s.hits.Store(0)
+	s.misses.Store(0)
+	s.expirations.Store(0)
+	s.evictions.Store(0)
+	s.loadsInitiated.Store(0)
+	s.loadsDeduplicated.Store(0)
+	s.loadErrors.Store(0)
+}
+
+func (st *Stats) add(other Stats) {
+	st.Hits += other.Hits
+	st.Misses += other.Misses
+	st.Expirations += other.Expirations
+	st.Evictions += other.Evictions
+	st.LoadsInitiated += other.LoadsInitiated
+	st.LoadsDeduplicated += other.LoadsDeduplicated
+	st.LoadErrors += other.LoadErrors
+}
+
+func (st *Stats) computeHitRatio() {
+	if total := st.Hits + st.Misses; total > 0 {
+		st.HitRatio = float64(st.Hits) / float64(total)
+	}
+}
+
+func (c *Cache[K, V]) Stats() Stats {
+	return c.stats.snapshot()
+}
+
+func (c *Cache[K, V]) ResetStats() {
+	c.stats.reset()
+}
+
+func (sc *ShardedCache[K, V]) Stats() Stats {
+	var st Stats
+	for _, c := range sc.shards {
+		st.add(c.Stats())
+	}
+	st.computeHitRatio()
+
+	return st
+}
+
+func (sc *ShardedCache[K, V]) ShardStats() []ShardStats {
+	result := make([]ShardStats, len(sc.shards))
+	for i, c := range sc.shards {
+		result[i] = ShardStats{Stats: c.Stats(), Count: c.Count()}
+	}
+
+	return result
+}
+
+func (sc *ShardedCache[K, V]) ResetStats() {
+	for _, c := range sc.shards {
+		c.ResetStats()
+	}
+}
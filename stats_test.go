@@ -0,0 +1,210 @@
+package mcache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Get("a")
+	c.Get("a")
+	c.Get("a")
+	c.Get("missing")
+
+	st := c.Stats()
+	if st.Hits != 3 {
+		t.Fatalf("expected 3 hits, got %d", st.Hits)
+	}
+	if st.Misses != 1 {
+		t.Fatalf("expected 1 miss, got %d", st.Misses)
+	}
+	if st.HitRatio != 0.75 {
+		t.Fatalf("expected hit ratio 0.75, got %v", st.HitRatio)
+	}
+}
+
+func TestStats_Empty(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	if st := c.Stats(); st != (Stats{}) {
+		t.Fatalf("expected zero stats, got %+v", st)
+	}
+}
+
+func TestResetStats(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Get("a")
+	c.Get("missing")
+	c.ResetStats()
+
+	if st := c.Stats(); st != (Stats{}) {
+		t.Fatalf("expected zero stats after reset, got %+v", st)
+	}
+}
+
+func TestStats_GetOrCompute(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	loader := func() (int, error) { return 1, nil }
+
+	c.GetOrCompute("a", loader)
+	c.GetOrCompute("a", loader)
+
+	st := c.Stats()
+	if st.Hits != 1 || st.Misses != 1 {
+		t.Fatalf("expected 1 hit and 1 miss, got %+v", st)
+	}
+}
+
+func TestStats_LoadDeduplication(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go c.GetOrCompute("a", func() (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.GetOrCompute("a", func() (int, error) { return 2, nil })
+		}()
+	}
+	waitForWaiters(t, c, "a", 4)
+	close(release)
+	wg.Wait()
+
+	c.GetOrCompute("a", func() (int, error) { return 3, nil })
+	c.GetOrCompute("b", func() (int, error) { return 4, nil })
+
+	st := c.Stats()
+	if st.LoadsInitiated != 2 || st.LoadsDeduplicated != 3 {
+		t.Fatalf("expected 2 initiated and 3 deduplicated loads, got %+v", st)
+	}
+
+	c.ResetStats()
+	if st := c.Stats(); st != (Stats{}) {
+		t.Fatalf("expected zero stats after reset, got %+v", st)
+	}
+}
+
+func TestShardedCache_Stats(t *testing.T) {
+	c := newTestShardedCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Get("a")
+	c.Get("b")
+	c.Get("missing")
+
+	st := c.Stats()
+	if st.Hits != 2 || st.Misses != 1 {
+		t.Fatalf("expected 2 hits and 1 miss, got %+v", st)
+	}
+
+	c.ResetStats()
+
+	if st := c.Stats(); st != (Stats{}) {
+		t.Fatalf("expected zero stats after reset, got %+v", st)
+	}
+}
+
+func TestStats_Expirations(t *testing.T) {
+	c := newTestCache(WithCleanupInterval[string, int](time.Hour))
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	advance(c, ttl)
+
+	c.Get("a")
+	if n := c.Stats().Expirations; n != 0 {
+		t.Fatalf("expected reads not to reap, got %d expirations", n)
+	}
+
+	c.Set("a", 3)
+	if n := c.Stats().Expirations; n != 1 {
+		t.Fatalf("expected 1 expiration after overwriting an expired key, got %d", n)
+	}
+
+	c.DeleteExpired()
+	if n := c.Stats().Expirations; n != 2 {
+		t.Fatalf("expected 2 expirations after sweep, got %d", n)
+	}
+
+	c.ResetStats()
+
+	if n := c.Stats().Expirations; n != 0 {
+		t.Fatalf("expected 0 expirations after reset, got %d", n)
+	}
+}
+
+func TestStats_ExpirationsByCleanup(t *testing.T) {
+	c := newTestCache()
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	advance(c, ttl)
+	time.Sleep(cleanupInterval + 10*time.Millisecond)
+
+	if n := c.Stats().Expirations; n != 2 {
+		t.Fatalf("expected 2 expirations after cleanup, got %d", n)
+	}
+}
+
+func TestShardedCache_ShardStats(t *testing.T) {
+	c := newTestShardedCache()
+	defer c.Close()
+
+	for i := range 100 {
+		c.Set(strconv.Itoa(i), i)
+	}
+	c.Get("0")
+	c.Get("missing")
+
+	shards := c.ShardStats()
+	if len(shards) != 4 {
+		t.Fatalf("expected 4 shards, got %d", len(shards))
+	}
+
+	var count int
+	var total Stats
+	for i, st := range shards {
+		if st.Count != c.shards[i].Count() {
+			t.Fatalf("shard %d: expected count %d, got %d", i, c.shards[i].Count(), st.Count)
+		}
+		count += st.Count
+		total.add(st.Stats)
+	}
+	if count != 100 {
+		t.Fatalf("expected shard counts to add up to 100, got %d", count)
+	}
+	if total.Hits != 1 || total.Misses != 1 {
+		t.Fatalf("expected 1 hit and 1 miss across shards, got %+v", total)
+	}
+	for i, shard := range c.shards {
+		if shard == c.shard("0") && shards[i].Hits != 1 {
+			t.Fatalf("expected the hit to be recorded on the key's shard, got %+v", shards[i])
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package mcache
+
+import (
+	"errors"
+	"sync"
+)
+
+type Store[K comparable, V any] interface {
+	Load(key K) (V, bool, error)
+	Save(key K, value V) error
+	Remove(key K) error
+}
+
+type storeOp[K comparable, V any] struct {
+	key    K
+	value  V
+	remove bool
+}
+
+// storeKey tracks the reads and writes of a key that are in progress. A
+// read-through only fills the cache if no write overlapped its Load, which
+// it detects by writes still running or by a change of gen.
+type storeKey struct {
+	loads   int
+	writers int
+	gen     uint64
+}
+
+type StoreCache[K comparable, V any] struct {
+	*Cache[K, V]
+	store        Store[K, V]
+	onStoreError func(key K, err error)
+	writeBehind  int
+
+	keysMu sync.Mutex
+	keys   map[K]*storeKey
+
+	mu       sync.Mutex
+	space    sync.Cond
+	closed   bool
+	pending  map[K]storeOp[K, V]
+	queue    []K
+	inflight map[K]storeOp[K, V]
+	failed   map[K]error
+
+	flushMu sync.Mutex
+	wake    chan struct{}
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+func NewStoreCache[K comparable, V any](store Store[K, V], opts ...Option[K, V]) *StoreCache[K, V] {
+	o := defaultOptions[K, V]()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	c := &StoreCache[K, V]{
+		Cache:        NewCache(opts...),
+		store:        store,
+		onStoreError: o.onStoreError,
+		writeBehind:  o.writeBehind,
+		keys:         make(map[K]*storeKey),
+	}
+
+	if c.writeBehind > 0 {
+		c.space.L = &c.mu
+		c.pending = make(map[K]storeOp[K, V])
+		c.failed = make(map[K]error)
+		c.wake = make(chan struct{}, 1)
+		c.done = make(chan struct{})
+		c.wg.Add(1)
+		go c.worker()
+	}
+
+	return c
+}
+
+func (c *StoreCache[K, V]) Get(key K) (V, bool, error) {
+	if val, ok := c.Cache.Get(key); ok {
+		return val, true, nil
+	}
+
+	// Registering before checking the queue means a write either is still
+	// visible there or is seen by the fill.
+	sk, gen := c.startLoad(key)
+	defer c.endLoad(key, sk)
+
+	if op, ok := c.queued(key); ok {
+		if op.remove {
+			var zero V
+			return zero, false, nil
+		}
+		return op.value, true, nil
+	}
+
+	val, ok, err := c.store.Load(key)
+	if err != nil || !ok {
+		return val, false, err
+	}
+
+	c.setIfAbsent(key, val, 0, func() bool {
+		c.keysMu.Lock()
+		defer c.keysMu.Unlock()
+		return sk.writers == 0 && sk.gen == gen
+	})
+	return val, true, nil
+}
+
+func (c *StoreCache[K, V]) startLoad(key K) (*storeKey, uint64) {
+	c.keysMu.Lock()
+	defer c.keysMu.Unlock()
+
+	sk := c.key(key)
+	sk.loads++
+	return sk, sk.gen
+}
+
+func (c *StoreCache[K, V]) endLoad(key K, sk *storeKey) {
+	c.keysMu.Lock()
+	defer c.keysMu.Unlock()
+
+	sk.loads--
+	c.release(key, sk)
+}
+
+func (c *StoreCache[K, V]) startWrite(key K) {
+	c.keysMu.Lock()
+	defer c.keysMu.Unlock()
+
+	c.key(key).writers++
+}
+
+// endWrite bumps gen, so a load that started while the write was running
+// does not fill the cache once it has finished.
+func (c *StoreCache[K, V]) endWrite(key K) {
+	c.keysMu.Lock()
+	defer c.keysMu.Unlock()
+
+	sk := c.keys[key]
+	sk.writers--
+	sk.gen++
+	c.release(key, sk)
+}
+
+func (c *StoreCache[K, V]) key(key K) *storeKey {
+	sk, ok := c.keys[key]
+	if !ok {
+		sk = &storeKey{}
+		c.keys[key] = sk
+	}
+	return sk
+}
+
+func (c *StoreCache[K, V]) release(key K, sk *storeKey) {
+	if sk.loads == 0 && sk.writers == 0 {
+		delete(c.keys, key)
+	}
+}
+
+// queued returns a write that has not reached the store yet, so a
+// read-through does not load a value that is about to be overwritten.
+func (c *StoreCache[K, V]) queued(key K) (storeOp[K, V], bool) {
+	if c.writeBehind == 0 {
+		return storeOp[K, V]{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if op, ok := c.pending[key]; ok {
+		return op, true
+	}
+	op, ok := c.inflight[key]
+	return op, ok
+}
+
+func (c *StoreCache[K, V]) Set(key K, value V) error {
+	c.startWrite(key)
+	defer c.endWrite(key)
+
+	if c.writeBehind > 0 {
+		c.Cache.Set(key, value)
+		c.enqueue(storeOp[K, V]{key: key, value: value})
+		return nil
+	}
+
+	if err := c.store.Save(key, value); err != nil {
+		return err
+	}
+
+	c.Cache.Set(key, value)
+	return nil
+}
+
+func (c *StoreCache[K, V]) Delete(key K) error {
+	c.startWrite(key)
+	defer c.endWrite(key)
+
+	if c.writeBehind > 0 {
+		c.Cache.Delete(key)
+		c.enqueue(storeOp[K, V]{key: key, remove: true})
+		return nil
+	}
+
+	if err := c.store.Remove(key); err != nil {
+		return err
+	}
+
+	c.Cache.Delete(key)
+	return nil
+}
+
+// enqueue replaces any pending write for the same key. A write for a new
+// key blocks while the queue is full, so write-behind applies backpressure
+// instead of dropping writes.
+func (c *StoreCache[K, V]) enqueue(op storeOp[K, V]) {
+	c.mu.Lock()
+
+	_, ok := c.pending[op.key]
+	for !ok && !c.closed && len(c.pending) >= c.writeBehind {
+		c.space.Wait()
+		_, ok = c.pending[op.key]
+	}
+
+	if c.closed {
+		c.mu.Unlock()
+		return
+	}
+
+	if !ok {
+		c.queue = append(c.queue, op.key)
+	}
+	c.pending[op.key] = op
+	c.mu.Unlock()
+
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (c *StoreCache[K, V]) worker() {
+	defer c.wg.Done()
+
+	for {
+		select {
+		case <-c.wake:
+			c.drain()
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// drain writes every pending operation to the store. flushMu keeps batches
+// from overlapping, so writes to a key reach the store in order.
+func (c *StoreCache[K, V]) drain() {
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+
+	c.mu.Lock()
+	queue, batch := c.queue, c.pending
+	c.queue, c.pending, c.inflight = nil, make(map[K]storeOp[K, V]), batch
+	c.space.Broadcast()
+	c.mu.Unlock()
+
+	for _, key := range queue {
+		op := batch[key]
+
+		var err error
+		if op.remove {
+			err = c.store.Remove(key)
+		} else {
+			err = c.store.Save(key, op.value)
+		}
+
+		c.mu.Lock()
+		if err != nil {
+			c.failed[key] = err
+		} else {
+			delete(c.failed, key)
+		}
+		c.mu.Unlock()
+
+		if err != nil && c.onStoreError != nil {
+			c.onStoreError(key, err)
+		}
+	}
+
+	c.mu.Lock()
+	c.inflight = nil
+	c.mu.Unlock()
+}
+
+func (c *StoreCache[K, V]) Flush() error {
+	if c.writeBehind == 0 {
+		return nil
+	}
+
+	c.drain()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	errs := make([]error, 0, len(c.failed))
+	for _, err := range c.failed {
+		errs = append(errs, err)
+	}
+	clear(c.failed)
+
+	return errors.Join(errs...)
+}
+
+func (c *StoreCache[K, V]) Close() {
+	c.Cache.Close()
+
+	if c.writeBehind == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return
+	}
+	c.closed = true
+	c.space.Broadcast()
+	c.mu.Unlock()
+
+	close(c.done)
+	c.wg.Wait()
+	c.drain()
+}
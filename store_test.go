@@ -0,0 +1,449 @@
+package mcache
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeStore struct {
+	mu      sync.Mutex
+	items   map[string]int
+	loads   int
+	saves   int
+	loadErr error
+	saveErr error
+	saving  chan string
+	block   chan struct{}
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{items: make(map[string]int)}
+}
+
+func (s *fakeStore) Load(key string) (int, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.loads++
+	if s.loadErr != nil {
+		return 0, false, s.loadErr
+	}
+	val, ok := s.items[key]
+	return val, ok, nil
+}
+
+func (s *fakeStore) Save(key string, value int) error {
+	if s.saving != nil {
+		s.saving <- key
+	}
+	if s.block != nil {
+		<-s.block
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.saves++
+	if s.saveErr != nil {
+		return s.saveErr
+	}
+	s.items[key] = value
+	return nil
+}
+
+func (s *fakeStore) Remove(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.saveErr != nil {
+		return s.saveErr
+	}
+	delete(s.items, key)
+	return nil
+}
+
+func (s *fakeStore) get(key string) (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	val, ok := s.items[key]
+	return val, ok
+}
+
+// blockingStore loads a value and then holds it until release is closed,
+// like a slow store answering with what it had when the read began.
+type blockingStore struct {
+	*fakeStore
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBlockingStore() *blockingStore {
+	return &blockingStore{
+		fakeStore: newFakeStore(),
+		started:   make(chan struct{}),
+		release:   make(chan struct{}),
+	}
+}
+
+func (s *blockingStore) Load(key string) (int, bool, error) {
+	val, ok, err := s.fakeStore.Load(key)
+	close(s.started)
+	<-s.release
+	return val, ok, err
+}
+
+func newTestStoreCache(store Store[string, int], opts ...Option[string, int]) *StoreCache[string, int] {
+	opts = append([]Option[string, int]{
+		WithTTL[string, int](ttl),
+		WithCleanupInterval[string, int](0),
+		WithClock[string, int](newFakeClock()),
+	}, opts...)
+	return NewStoreCache(store, opts...)
+}
+
+func TestStoreCache_ReadThrough(t *testing.T) {
+	store := newFakeStore()
+	store.items["a"] = 1
+
+	c := newTestStoreCache(store)
+	defer c.Close()
+
+	for range 2 {
+		val, ok, err := c.Get("a")
+		if err != nil || !ok || val != 1 {
+			t.Fatalf("expected 1, got %d, %v, %v", val, ok, err)
+		}
+	}
+
+	if store.loads != 1 {
+		t.Fatalf("expected the store to be read once, got %d", store.loads)
+	}
+	if store.saves != 0 {
+		t.Fatalf("expected a read-through not to write back, got %d saves", store.saves)
+	}
+}
+
+func TestStoreCache_ReadThroughKeepsConcurrentWrite(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		write func(c *StoreCache[string, int]) error
+		want  bool
+	}{
+		{"Set", func(c *StoreCache[string, int]) error { return c.Set("a", 2) }, true},
+		{"Delete", func(c *StoreCache[string, int]) error { return c.Delete("a") }, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newBlockingStore()
+			store.items["a"] = 1
+
+			c := newTestStoreCache(store)
+			defer c.Close()
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				c.Get("a")
+			}()
+
+			<-store.started
+			if err := tc.write(c); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			close(store.release)
+			<-done
+
+			stored, _ := store.get("a")
+			cached, ok := c.Cache.Get("a")
+			if ok != tc.want || ok && cached != stored {
+				t.Fatalf("expected the cache to match the store, store has %d, cache has %d, %v", stored, cached, ok)
+			}
+		})
+	}
+}
+
+func TestStoreCache_MissAndLoadError(t *testing.T) {
+	store := newFakeStore()
+	c := newTestStoreCache(store)
+	defer c.Close()
+
+	if _, ok, err := c.Get("a"); ok || err != nil {
+		t.Fatalf("expected a miss, got %v, %v", ok, err)
+	}
+
+	errLoad := errors.New("store down")
+	store.loadErr = errLoad
+	if _, ok, err := c.Get("a"); ok || !errors.Is(err, errLoad) {
+		t.Fatalf("expected store error, got %v, %v", ok, err)
+	}
+	if c.Has("a") {
+		t.Fatal("expected nothing to be cached")
+	}
+}
+
+func TestStoreCache_WriteThrough(t *testing.T) {
+	store := newFakeStore()
+	c := newTestStoreCache(store)
+	defer c.Close()
+
+	if err := c.Set("a", 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if val, ok := store.get("a"); !ok || val != 1 {
+		t.Fatal("expected value to be saved to the store")
+	}
+	if val, ok := c.Peek("a"); !ok || val != 1 {
+		t.Fatal("expected value to be cached")
+	}
+
+	if err := c.Delete("a"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := store.get("a"); ok {
+		t.Fatal("expected value to be removed from the store")
+	}
+	if c.Has("a") {
+		t.Fatal("expected value to be removed from the cache")
+	}
+}
+
+func TestStoreCache_WriteThroughError(t *testing.T) {
+	store := newFakeStore()
+	c := newTestStoreCache(store)
+	defer c.Close()
+
+	c.Set("a", 1)
+
+	store.saveErr = errors.New("store down")
+	if err := c.Set("b", 2); err == nil {
+		t.Fatal("expected store error")
+	}
+	if c.Has("b") {
+		t.Fatal("expected a failed save not to be cached")
+	}
+	if err := c.Delete("a"); err == nil {
+		t.Fatal("expected store error")
+	}
+	if !c.Has("a") {
+		t.Fatal("expected a failed remove to keep the cached value")
+	}
+}
+
+func TestStoreCache_WriteBehind(t *testing.T) {
+	store := newFakeStore()
+	store.block = make(chan struct{})
+
+	c := newTestStoreCache(store, WithWriteBehind[string, int](4))
+
+	if err := c.Set("a", 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if val, ok := c.Peek("a"); !ok || val != 1 {
+		t.Fatal("expected value to be cached before it is saved")
+	}
+	if _, ok := store.get("a"); ok {
+		t.Fatal("expected save to happen in the background")
+	}
+
+	c.Set("b", 2)
+	c.Delete("a")
+
+	close(store.block)
+	c.Close()
+
+	if _, ok := store.get("a"); ok {
+		t.Fatal("expected queued remove to be applied in order")
+	}
+	if val, ok := store.get("b"); !ok || val != 2 {
+		t.Fatal("expected Close to drain queued writes")
+	}
+}
+
+func TestStoreCache_WriteBehindError(t *testing.T) {
+	errSave := errors.New("store down")
+	store := newFakeStore()
+	store.saveErr = errSave
+
+	var failed []string
+	c := newTestStoreCache(store,
+		WithWriteBehind[string, int](1),
+		WithOnStoreError[string, int](func(key string, err error) {
+			if errors.Is(err, errSave) {
+				failed = append(failed, key)
+			}
+		}),
+	)
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Close()
+
+	if len(failed) != 2 || failed[0] != "a" || failed[1] != "b" {
+		t.Fatalf("expected both failed writes to be reported, got %v", failed)
+	}
+}
+
+func TestStoreCache_WriteBehindAfterClose(t *testing.T) {
+	store := newFakeStore()
+	c := newTestStoreCache(store, WithWriteBehind[string, int](1))
+	c.Close()
+	c.Close()
+
+	if err := c.Set("a", 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := store.get("a"); ok {
+		t.Fatal("expected writes after Close to be dropped")
+	}
+}
+
+func TestStoreCache_WriteBehindCoalesces(t *testing.T) {
+	store := newFakeStore()
+	store.saving = make(chan string, 10)
+	store.block = make(chan struct{})
+
+	c := newTestStoreCache(store, WithWriteBehind[string, int](4))
+	defer c.Close()
+
+	c.Set("a", 1)
+	<-store.saving
+
+	for i := 2; i <= 4; i++ {
+		c.Set("a", i)
+	}
+	close(store.block)
+
+	if err := c.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if store.saves != 2 {
+		t.Fatalf("expected queued writes to the same key to be coalesced, got %d saves", store.saves)
+	}
+	if val, ok := store.get("a"); !ok || val != 4 {
+		t.Fatalf("expected the latest value to be saved, got %d, %v", val, ok)
+	}
+}
+
+func TestStoreCache_WriteBehindCoalescesDelete(t *testing.T) {
+	store := newFakeStore()
+	store.items["a"] = 1
+	store.saving = make(chan string, 10)
+	store.block = make(chan struct{})
+
+	c := newTestStoreCache(store, WithWriteBehind[string, int](4))
+	defer c.Close()
+
+	c.Set("b", 1)
+	<-store.saving
+
+	c.Set("a", 2)
+	c.Delete("a")
+
+	if _, ok, _ := c.Get("a"); ok {
+		t.Fatal("expected a queued remove to hide the stored value")
+	}
+
+	close(store.block)
+	c.Flush()
+
+	if store.saves != 1 {
+		t.Fatalf("expected the set to be replaced by the remove, got %d saves", store.saves)
+	}
+	if _, ok := store.get("a"); ok {
+		t.Fatal("expected the key to be removed from the store")
+	}
+}
+
+func TestStoreCache_ReadThroughSeesQueuedWrite(t *testing.T) {
+	store := newFakeStore()
+	store.block = make(chan struct{})
+
+	c := newTestStoreCache(store, WithWriteBehind[string, int](4))
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.Cache.Delete("a")
+
+	if val, ok, err := c.Get("a"); err != nil || !ok || val != 1 {
+		t.Fatalf("expected the queued value, got %d, %v, %v", val, ok, err)
+	}
+	if store.loads != 0 {
+		t.Fatalf("expected the store not to be read, got %d loads", store.loads)
+	}
+
+	close(store.block)
+}
+
+func TestStoreCache_WriteBehindBlocksWhenFull(t *testing.T) {
+	store := newFakeStore()
+	store.saving = make(chan string, 10)
+	store.block = make(chan struct{})
+
+	c := newTestStoreCache(store, WithWriteBehind[string, int](1))
+	defer c.Close()
+
+	c.Set("a", 1)
+	<-store.saving
+	c.Set("b", 2)
+
+	done := make(chan struct{})
+	go func() {
+		c.Set("c", 3)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("expected Set to block while the queue is full")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	c.Set("b", 3)
+
+	close(store.block)
+	<-done
+
+	c.Flush()
+	for key, want := range map[string]int{"a": 1, "b": 3, "c": 3} {
+		if val, ok := store.get(key); !ok || val != want {
+			t.Fatalf("expected %s=%d in the store, got %d, %v", key, want, val, ok)
+		}
+	}
+}
+
+func TestStoreCache_FlushReturnsErrors(t *testing.T) {
+	errSave := errors.New("store down")
+	store := newFakeStore()
+	store.saveErr = errSave
+
+	c := newTestStoreCache(store, WithWriteBehind[string, int](4))
+	defer c.Close()
+
+	c.Set("a", 1)
+	if err := c.Flush(); !errors.Is(err, errSave) {
+		t.Fatalf("expected %v, got %v", errSave, err)
+	}
+	if err := c.Flush(); err != nil {
+		t.Fatalf("expected errors to be reported once, got %v", err)
+	}
+
+	store.mu.Lock()
+	store.saveErr = nil
+	store.mu.Unlock()
+
+	c.Set("a", 2)
+	if err := c.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestStoreCache_FlushWriteThrough(t *testing.T) {
+	c := newTestStoreCache(newFakeStore())
+	defer c.Close()
+
+	if err := c.Flush(); err != nil {
+		t.Fatalf("expected Flush to be a no-op, got %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package mcache
+
+import "time"
+
+type Backend[K comparable, V any] interface {
+	Get(key K) (V, bool, error)
+	Set(key K, value V) error
+}
+
+type TieredCache[K comparable, V any] struct {
+	*Cache[K, V]
+	backend      Backend[K, V]
+	promotionTTL time.Duration
+}
+
+func NewTieredCache[K comparable, V any](backend Backend[K, V], opts ...Option[K, V]) *TieredCache[K, V] {
+	o := defaultOptions[K, V]()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &TieredCache[K, V]{
+		Cache:        NewCache(opts...),
+		backend:      backend,
+		promotionTTL: o.promotionTTL,
+	}
+}
+
+func (t *TieredCache[K, V]) Get(key K) (V, bool, error) {
+	if val, ok := t.Cache.Get(key); ok {
+		return val, true, nil
+	}
+
+	val, ok, err := t.backend.Get(key)
+	if err != nil || !ok {
+		return val, false, err
+	}
+
+	// A Set that ran during the backend read has the newer value.
+	t.setIfAbsent(key, val, t.promotionTTL, nil)
+	return val, true, nil
+}
+
+func (t *TieredCache[K, V]) Set(key K, value V) error {
+	if err := t.backend.Set(key, value); err != nil {
+		return err
+	}
+
+	t.Cache.Set(key, value)
+	return nil
+}
+
+type cacheBackend[K comparable, V any] struct {
+	c *Cache[K, V]
+}
+
+func NewCacheBackend[K comparable, V any](c *Cache[K, V]) Backend[K, V] {
+	return cacheBackend[K, V]{c: c}
+}
+
+func (b cacheBackend[K, V]) Get(key K) (V, bool, error) {
+	val, ok := b.c.Get(key)
+	return val, ok, nil
+}
+
+func (b cacheBackend[K, V]) Set(key K, value V) error {
+	b.c.Set(key, value)
+	return nil
+}
@@ -0,0 +1,209 @@
+package mcache
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeBackend struct {
+	mu     sync.Mutex
+	items  map[string]int
+	gets   int
+	getErr error
+	setErr error
+}
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{items: make(map[string]int)}
+}
+
+func (b *fakeBackend) Get(key string) (int, bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.gets++
+	if b.getErr != nil {
+		return 0, false, b.getErr
+	}
+	val, ok := b.items[key]
+	return val, ok, nil
+}
+
+func (b *fakeBackend) Set(key string, value int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.setErr != nil {
+		return b.setErr
+	}
+	b.items[key] = value
+	return nil
+}
+
+// blockingBackend reads a value and then holds it until release is closed,
+// like a slow backend answering with what it had when the read began.
+type blockingBackend struct {
+	*fakeBackend
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingBackend) Get(key string) (int, bool, error) {
+	val, ok, err := b.fakeBackend.Get(key)
+	close(b.started)
+	<-b.release
+	return val, ok, err
+}
+
+func newTestTieredCache(backend Backend[string, int], opts ...Option[string, int]) *TieredCache[string, int] {
+	opts = append([]Option[string, int]{
+		WithTTL[string, int](ttl),
+		WithCleanupInterval[string, int](0),
+		WithClock[string, int](newFakeClock()),
+	}, opts...)
+	return NewTieredCache(backend, opts...)
+}
+
+func TestTieredCache_FallsThroughAndPromotes(t *testing.T) {
+	backend := newFakeBackend()
+	backend.items["a"] = 1
+
+	c := newTestTieredCache(backend)
+	defer c.Close()
+
+	for range 2 {
+		val, ok, err := c.Get("a")
+		if err != nil || !ok || val != 1 {
+			t.Fatalf("expected 1, got %d, %v, %v", val, ok, err)
+		}
+	}
+
+	if backend.gets != 1 {
+		t.Fatalf("expected the backend to be read once, got %d", backend.gets)
+	}
+	if remaining, ok := c.GetTTL("a"); !ok || remaining != ttl {
+		t.Fatalf("expected promoted entry to get the cache ttl, got %v, %v", remaining, ok)
+	}
+}
+
+func TestTieredCache_PromotionTTL(t *testing.T) {
+	backend := newFakeBackend()
+	backend.items["a"] = 1
+
+	c := newTestTieredCache(backend, WithPromotionTTL[string, int](ttl/4))
+	defer c.Close()
+
+	c.Get("a")
+
+	if remaining, ok := c.GetTTL("a"); !ok || remaining != ttl/4 {
+		t.Fatalf("expected promotion ttl %v, got %v, %v", ttl/4, remaining, ok)
+	}
+
+	advance(c.Cache, ttl/4)
+	c.Get("a")
+
+	if backend.gets != 2 {
+		t.Fatalf("expected the backend to be read again after the promoted entry expired, got %d", backend.gets)
+	}
+}
+
+func TestTieredCache_MissInBoth(t *testing.T) {
+	backend := newFakeBackend()
+	c := newTestTieredCache(backend)
+	defer c.Close()
+
+	if _, ok, err := c.Get("a"); ok || err != nil {
+		t.Fatalf("expected a miss, got %v, %v", ok, err)
+	}
+	if c.Has("a") {
+		t.Fatal("expected nothing to be promoted")
+	}
+}
+
+func TestTieredCache_BackendError(t *testing.T) {
+	errBackend := errors.New("backend down")
+	backend := newFakeBackend()
+	backend.getErr = errBackend
+
+	c := newTestTieredCache(backend)
+	defer c.Close()
+
+	if _, ok, err := c.Get("a"); ok || !errors.Is(err, errBackend) {
+		t.Fatalf("expected backend error, got %v, %v", ok, err)
+	}
+}
+
+func TestTieredCache_SetWritesBothTiers(t *testing.T) {
+	backend := newFakeBackend()
+	c := newTestTieredCache(backend)
+	defer c.Close()
+
+	if err := c.Set("a", 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if backend.items["a"] != 1 {
+		t.Fatal("expected value to be written to the backend")
+	}
+	if val, ok := c.Peek("a"); !ok || val != 1 {
+		t.Fatal("expected value to be written to the fast tier")
+	}
+
+	backend.setErr = errors.New("backend down")
+	if err := c.Set("b", 2); err == nil {
+		t.Fatal("expected backend error")
+	}
+	if c.Has("b") {
+		t.Fatal("expected failed write not to reach the fast tier")
+	}
+}
+
+func TestTieredCache_PromotionKeepsConcurrentSet(t *testing.T) {
+	backend := &blockingBackend{
+		fakeBackend: newFakeBackend(),
+		started:     make(chan struct{}),
+		release:     make(chan struct{}),
+	}
+	backend.items["a"] = 1
+
+	c := newTestTieredCache(backend)
+	defer c.Close()
+
+	done := make(chan int)
+	go func() {
+		val, _, _ := c.Get("a")
+		done <- val
+	}()
+
+	<-backend.started
+	if err := c.Set("a", 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	close(backend.release)
+
+	if val := <-done; val != 1 {
+		t.Fatalf("expected the backend value, got %d", val)
+	}
+	if val, ok := c.Cache.Get("a"); !ok || val != 2 {
+		t.Fatalf("expected the fast tier to keep the newer value, got %d, %v", val, ok)
+	}
+}
+
+func TestTieredCache_CacheBackend(t *testing.T) {
+	l2 := NewCache(WithTTL[string, int](time.Hour))
+	defer l2.Close()
+	l2.Set("a", 1)
+
+	l1 := newTestTieredCache(NewCacheBackend(l2))
+	defer l1.Close()
+
+	if val, ok, err := l1.Get("a"); err != nil || !ok || val != 1 {
+		t.Fatalf("expected 1 from the second tier, got %d, %v, %v", val, ok, err)
+	}
+
+	l1.Set("b", 2)
+	if val, ok := l2.Get("b"); !ok || val != 2 {
+		t.Fatalf("expected write to reach the second tier, got %d, %v", val, ok)
+	}
+}